@@ -0,0 +1,16 @@
+package main
+
+import (
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/module/scheduler"
+	"github.com/project-tktt/go-crawler/internal/module/sitemap"
+
+	// Registers the CareerViet crawlers
+	_ "github.com/project-tktt/go-crawler/internal/module/careerviet"
+)
+
+// Runs the CareerViet listing and sitemap crawlers (cmd/crawler with
+// CRAWLER_SOURCES=careerviet,careerviet_sitemap)
+func main() {
+	scheduler.Main("CareerViet Crawler", domain.SourceCareerViet, sitemap.SourceName(domain.SourceCareerViet))
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"github.com/project-tktt/go-crawler/internal/module/scheduler"
+
+	// Source packages register themselves with module.Register
+	_ "github.com/project-tktt/go-crawler/internal/module/careerviet"
+	_ "github.com/project-tktt/go-crawler/internal/module/topcv"
+	_ "github.com/project-tktt/go-crawler/internal/module/topdev"
+	_ "github.com/project-tktt/go-crawler/internal/module/vieclam24h"
+	_ "github.com/project-tktt/go-crawler/internal/module/vietnamworks"
+)
+
+// Runs the sources listed in CRAWLER_SOURCES (default: all registered)
+func main() {
+	scheduler.Main("Crawler")
+}
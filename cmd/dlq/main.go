@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/config"
+	"github.com/project-tktt/go-crawler/internal/queue"
+	"github.com/redis/go-redis/v9"
+)
+
+const usage = `dlq inspects, replays and purges dead-lettered jobs
+
+	go run ./cmd/dlq stages
+	go run ./cmd/dlq list   -stage worker [-source topcv] [-job ID] [-run RUN] [-error text] [-since 24h] [-limit 50]
+	go run ./cmd/dlq show   -stage worker -id topcv:123
+	go run ./cmd/dlq replay -stage worker [filters]
+	go run ./cmd/dlq purge  -stage worker [filters | -all]
+
+Replay pushes the original jobs back to the queues they came from (the
+group's own stream for stream workers, so other groups do not get them
+again), with a new round of retry attempts in their envelope; jobs whose
+payload cannot be read are skipped, reported and left in the stage
+`
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command := os.Args[1]
+
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	stage := fs.String("stage", "", "Pipeline stage (see: dlq stages)")
+	ids := fs.String("id", "", "Comma-separated dead letter IDs")
+	source := fs.String("source", "", "Only jobs of this source")
+	jobID := fs.String("job", "", "Only this job ID")
+	runID := fs.String("run", "", "Only jobs of this crawl run")
+	errText := fs.String("error", "", "Only errors containing this text")
+	since := fs.Duration("since", 0, "Only jobs that failed within this duration (e.g. 2h)")
+	limit := fs.Int("limit", 0, "Maximum number of jobs (0 = all)")
+	all := fs.Bool("all", false, "Purge every job of the stage")
+	fs.Parse(os.Args[2:])
+
+	cfg := config.Load()
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	ctx := context.Background()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		log.Fatalf("Redis connection failed: %v", err)
+	}
+
+	store := queue.NewDeadLetterStore(rdb, queue.DeadLetterConfig{MaxLen: cfg.Redis.DeadLetterMaxLen})
+
+	filter := queue.DeadLetterFilter{
+		Source: *source,
+		JobID:  *jobID,
+		RunID:  *runID,
+		Error:  *errText,
+		Limit:  *limit,
+	}
+	if *ids != "" {
+		filter.IDs = strings.Split(*ids, ",")
+	}
+	if *since > 0 {
+		filter.Since = time.Now().Add(-*since)
+	}
+
+	if command != "stages" && *stage == "" {
+		log.Fatalf("-stage is required")
+	}
+
+	switch command {
+	case "stages":
+		stages, err := store.Stages(ctx)
+		if err != nil {
+			log.Fatalf("Stages: %v", err)
+		}
+		names := make([]string, 0, len(stages))
+		for name := range stages {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%-30s %d\n", name, stages[name])
+		}
+
+	case "list":
+		entries, err := store.List(ctx, *stage, filter)
+		if err != nil {
+			log.Fatalf("List: %v", err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "FAILED AT\tATTEMPTS\tID\tQUEUE\tERROR")
+		for _, dl := range entries {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n",
+				dl.FailedAt.Local().Format(time.DateTime), dl.Attempts, dl.ID, dl.Queue, truncate(dl.Error, 120))
+		}
+		tw.Flush()
+		log.Printf("%d jobs", len(entries))
+
+	case "show":
+		if len(filter.IDs) == 0 {
+			log.Fatalf("-id is required")
+		}
+		entries, err := store.List(ctx, *stage, filter)
+		if err != nil {
+			log.Fatalf("Show: %v", err)
+		}
+		if len(entries) == 0 {
+			log.Fatalf("No dead letter %s in stage %s", *ids, *stage)
+		}
+		for _, dl := range entries {
+			show(dl)
+		}
+
+	case "replay":
+		publishers := make(map[string]queue.Publisher)
+		open := func(queueName string) (queue.Publisher, error) {
+			if p, ok := publishers[queueName]; ok {
+				return p, nil
+			}
+			p, err := queue.OpenPublisher(ctx, rdb, queueName, cfg.Redis.JobQueueMaxLen)
+			if err != nil {
+				return nil, err
+			}
+			publishers[queueName] = p
+			return p, nil
+		}
+		n, skipped, err := store.Replay(ctx, *stage, filter, open)
+		if skipped > 0 {
+			log.Printf("Skipped %d jobs with an unreadable payload; they stay in %s (inspect with show, remove with purge)", skipped, *stage)
+		}
+		if err != nil {
+			log.Fatalf("Replay (%d replayed): %v", n, err)
+		}
+		log.Printf("Replayed %d jobs from %s", n, *stage)
+
+	case "purge":
+		filtered := len(filter.IDs) > 0 || filter.Source != "" || filter.JobID != "" || filter.RunID != "" || filter.Error != "" || !filter.Since.IsZero()
+		if !filtered && !*all {
+			log.Fatalf("Refusing to purge the whole stage without -all")
+		}
+		n, err := store.Purge(ctx, *stage, filter)
+		if err != nil {
+			log.Fatalf("Purge: %v", err)
+		}
+		log.Printf("Purged %d jobs from %s", n, *stage)
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// show prints a dead letter with its payload indented
+func show(dl *queue.DeadLetter) {
+	payload := dl.Payload
+	dl.Payload = ""
+	meta, _ := json.MarshalIndent(dl, "", "  ")
+	fmt.Println(string(meta))
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, []byte(payload), "", "  "); err == nil {
+		payload = pretty.String()
+	}
+	fmt.Printf("payload:\n%s\n\n", payload)
+}
+
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
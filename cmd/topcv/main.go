@@ -0,0 +1,16 @@
+package main
+
+import (
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/module/scheduler"
+	"github.com/project-tktt/go-crawler/internal/module/sitemap"
+
+	// Registers the TopCV crawlers
+	_ "github.com/project-tktt/go-crawler/internal/module/topcv"
+)
+
+// Runs the TopCV listing and sitemap crawlers (cmd/crawler with
+// CRAWLER_SOURCES=topcv,topcv_sitemap)
+func main() {
+	scheduler.Main("TopCV Crawler", domain.SourceTopCV, sitemap.SourceName(domain.SourceTopCV))
+}
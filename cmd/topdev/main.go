@@ -0,0 +1,14 @@
+package main
+
+import (
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/module/scheduler"
+
+	// Registers the TopDev crawlers
+	_ "github.com/project-tktt/go-crawler/internal/module/topdev"
+)
+
+// Runs the TopDev crawler (cmd/crawler with CRAWLER_SOURCES=topdev)
+func main() {
+	scheduler.Main("TopDev Crawler", domain.SourceTopDev)
+}
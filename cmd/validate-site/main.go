@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/project-tktt/go-crawler/internal/common/extractor"
+)
+
+// validate-site checks a declarative site definition against a saved HTML fixture
+//
+//	go run ./cmd/validate-site -def configs/sites/example.yaml -fixture page.html
+//	go run ./cmd/validate-site -def configs/sites/example.yaml -fixture list.html -list
+func main() {
+	log.SetFlags(0)
+
+	defPath := flag.String("def", "", "Path to site definition (.yaml, .yml or .json)")
+	fixturePath := flag.String("fixture", "", "Path to saved HTML page")
+	pageURL := flag.String("url", "", "Original page URL (used to resolve relative links)")
+	list := flag.Bool("list", false, "Treat the fixture as a listing page")
+	flag.Parse()
+
+	if *defPath == "" || *fixturePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	def, err := extractor.LoadSiteDefinition(*defPath)
+	if err != nil {
+		log.Fatalf("Load definition: %v", err)
+	}
+
+	html, err := os.ReadFile(*fixturePath)
+	if err != nil {
+		log.Fatalf("Read fixture: %v", err)
+	}
+
+	result, err := def.Validate(string(html), *pageURL, *list)
+	if err != nil {
+		log.Fatalf("Validate: %v", err)
+	}
+
+	var out any = result.RawData
+	if *list {
+		out = result.Links
+	}
+	pretty, _ := json.MarshalIndent(out, "", "  ")
+	fmt.Println(string(pretty))
+
+	if len(result.Missing) > 0 {
+		log.Fatalf("FAIL %s: missing required fields: %v", def.Source, result.Missing)
+	}
+	log.Printf("OK %s", def.Source)
+}
@@ -9,16 +9,15 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/project-tktt/go-crawler/internal/common/httpcache"
 	"github.com/project-tktt/go-crawler/internal/config"
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/module"
 	"github.com/project-tktt/go-crawler/internal/module/vieclam24h"
 	"github.com/project-tktt/go-crawler/internal/queue"
 	"github.com/redis/go-redis/v9"
 )
 
-const (
-	PendingQueue = "jobs:pending:vieclam24h"
-)
-
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 	log.Println("Starting Vieclam24h Enricher Service")
@@ -42,14 +41,31 @@ func main() {
 	}
 	log.Println("Redis connected")
 
+	deps, err := module.NewDeps(cfg, rdb)
+	if err != nil {
+		log.Fatalf("Failed to initialize dependencies: %v", err)
+	}
+
 	// Queues
 	// Consumer: Pending Queue (from crawler)
-	pendingCons := queue.NewConsumer(rdb, PendingQueue, 5*time.Second)
+	// Jobs the enricher fails on are retried with backoff through the pending
+	// queue, then kept for inspection and replay (cmd/dlq)
+	const stage = "vieclam24h-enricher"
+	deadLetters := queue.NewDeadLetterStore(rdb, queue.DeadLetterConfig{MaxLen: cfg.Redis.DeadLetterMaxLen})
+	retrier := queue.NewRetrier(rdb, queue.NewPublisher(rdb, vieclam24h.PendingQueue), deadLetters, queue.RetryConfig{
+		Stage:       stage,
+		MaxAttempts: cfg.Retry.MaxAttemptsFor(stage),
+		BaseDelay:   cfg.Retry.BaseDelay,
+		MaxDelay:    cfg.Retry.MaxDelay,
+	})
+	pendingCons := queue.NewConsumer(rdb, vieclam24h.PendingQueue, 5*time.Second).
+		WithDeadLetters(deadLetters, stage).
+		WithRetries(retrier)
 
 	// Producer: Raw Queue (to worker) - Configurable via env
 	rawQueueName := cfg.Redis.JobQueue
 	log.Printf("Output queue: %s", rawQueueName)
-	rawPub := queue.NewPublisher(rdb, rawQueueName)
+	rawPub := deps.Publisher(rawQueueName)
 
 	// Producer: JSON-LD Validation Queue
 	jsonLdQueueName := "jobs:jsonld:vieclam24h"
@@ -57,7 +73,20 @@ func main() {
 	jsonLdPub := queue.NewPublisher(rdb, jsonLdQueueName)
 
 	// Initialize Detail Scraper (Consumer Pending -> Producer Raw + JSON-LD)
-	vl24hScraper := vieclam24h.NewScraper(pendingCons, rawPub, jsonLdPub, cfg.Crawler.RequestDelay)
+	// Shares the per-host budget and proxy pool with the vieclam24h listing crawler
+	deps.LimitHosts(domain.SourceVieclam24h, vieclam24h.WebHost)
+	// Own cassette so recording here does not truncate the listing crawler's
+	httpOpts := deps.HTTP(domain.SourceVieclam24h)
+	httpOpts.Cassette = deps.Cassettes.Cassette(string(domain.SourceVieclam24h) + "_enricher")
+	// Unchanged detail pages (304) reuse the cached enrichment
+	detailCache := httpcache.NewCache(rdb, httpcache.Config{
+		Prefix:      "httpcache:vieclam24h",
+		TTL:         cfg.Crawler.DetailCacheTTL,
+		MaxBodySize: cfg.Crawler.DetailCacheMaxKB << 10,
+	})
+	vl24hScraper := vieclam24h.NewScraper(pendingCons, rawPub, jsonLdPub, cfg.Crawler.RequestDelay).
+		WithHTTP(httpOpts).
+		WithCache(detailCache)
 
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -65,6 +94,13 @@ func main() {
 
 	var wg sync.WaitGroup
 
+	// Return jobs due for another attempt to the pending queue
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		retrier.RunMover(ctx)
+	}()
+
 	// Start Enricher Loop
 	wg.Add(1)
 	go func() {
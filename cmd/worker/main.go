@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -56,7 +57,24 @@ func main() {
 	// Initialize Components
 	htmlCleaner := cleaner.NewCleaner()
 	norm := normalizer.NewNormalizer()
-	consumer := queue.NewConsumer(rdb, cfg.Redis.JobQueue, 5*time.Second)
+	// Jobs that cannot be processed are kept for inspection and replay (cmd/dlq)
+	deadLetters := queue.NewDeadLetterStore(rdb, queue.DeadLetterConfig{MaxLen: cfg.Redis.DeadLetterMaxLen})
+	stage := deadLetterStage(cfg)
+	log.Printf("Dead-letter stage: %s", stage)
+	consumer, requeue := newConsumer(ctx, cfg, rdb, deadLetters, stage)
+	// Jobs of a failed bulk request go back to this worker's queue with backoff
+	retrier := queue.NewRetrier(rdb, requeue, deadLetters, queue.RetryConfig{
+		Stage:       stage,
+		MaxAttempts: cfg.Retry.MaxAttemptsFor(stage),
+		BaseDelay:   cfg.Retry.BaseDelay,
+		MaxDelay:    cfg.Retry.MaxDelay,
+	})
+	// Jobs a previous run of this worker had in flight go back to the queue
+	if n, err := consumer.Recover(ctx); err != nil {
+		log.Printf("Warning: Failed to recover in-flight jobs: %v", err)
+	} else if n > 0 {
+		log.Printf("Recovered %d in-flight jobs from a previous run", n)
+	}
 
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -64,6 +82,20 @@ func main() {
 
 	var wg sync.WaitGroup
 
+	// Return jobs stuck in flight (crashed or failing workers) to the queue
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		consumer.RunReaper(ctx)
+	}()
+
+	// Return jobs due for another attempt to the queue
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		retrier.RunMover(ctx)
+	}()
+
 	// Start worker pool (processes queue -> normalizes -> indexes to Elasticsearch)
 	wg.Add(1)
 	go func() {
@@ -71,7 +103,7 @@ func main() {
 		w := worker.NewWorker(consumer, norm, htmlCleaner, esIndexer, worker.Config{
 			Concurrency: cfg.Worker.Concurrency,
 			BatchSize:   cfg.Worker.BatchSize,
-		})
+		}).WithDeadLetters(deadLetters, stage).WithRetries(retrier)
 		if err := w.Run(ctx); err != nil && err != context.Canceled {
 			log.Printf("Worker error: %v", err)
 		}
@@ -96,3 +128,44 @@ func main() {
 		log.Println("Shutdown timeout, forcing exit")
 	}
 }
+
+// reliableQueue is a job queue with acks, startup recovery and a reaper
+type reliableQueue interface {
+	queue.Consumer
+	Recover(ctx context.Context) (int, error)
+	RunReaper(ctx context.Context)
+}
+
+// newConsumer opens the job queue with the configured backend
+// (REDIS_JOB_QUEUE_BACKEND): a consumer group of the stream, or a list
+// with a per-worker processing list; requeue redelivers jobs to this worker
+// only (the group's own stream, or the list itself)
+func newConsumer(ctx context.Context, cfg *config.Config, rdb *redis.Client, dl queue.DeadLetterer, stage string) (consumer reliableQueue, requeue *queue.RedisPublisher) {
+	if strings.EqualFold(cfg.Redis.JobQueueBackend, "stream") {
+		consumer := queue.NewStreamConsumer(rdb, cfg.Redis.JobQueue, queue.StreamConfig{
+			Group:             cfg.Worker.Group,
+			Name:              cfg.Worker.ConsumerName,
+			Timeout:           5 * time.Second,
+			VisibilityTimeout: cfg.Worker.VisibilityTimeout,
+		}).WithDeadLetters(dl, stage)
+		if err := consumer.EnsureGroup(ctx); err != nil {
+			log.Fatalf("Failed to create consumer group: %v", err)
+		}
+		log.Printf("Consuming stream %s as group %s (retries via %s)", cfg.Redis.JobQueue, cfg.Worker.Group, consumer.GroupStream())
+		return consumer, consumer.GroupPublisher()
+	}
+	return queue.NewReliableConsumer(rdb, cfg.Redis.JobQueue, queue.ReliableConfig{
+		Name:              cfg.Worker.ConsumerName,
+		Timeout:           5 * time.Second,
+		VisibilityTimeout: cfg.Worker.VisibilityTimeout,
+	}).WithDeadLetters(dl, stage), queue.NewPublisher(rdb, cfg.Redis.JobQueue)
+}
+
+// deadLetterStage names the worker's dead-letter stage; each consumer group
+// of a stream job queue gets its own, as groups fail independently
+func deadLetterStage(cfg *config.Config) string {
+	if strings.EqualFold(cfg.Redis.JobQueueBackend, "stream") {
+		return "worker-" + cfg.Worker.Group
+	}
+	return "worker"
+}
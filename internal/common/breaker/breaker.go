@@ -0,0 +1,426 @@
+package breaker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// State is the position of a source's circuit
+type State string
+
+const (
+	// StateClosed lets requests through (default)
+	StateClosed State = "closed"
+	// StateOpen rejects requests until the cooldown ends
+	StateOpen State = "open"
+	// StateHalfOpen lets probe requests through after the cooldown; the first
+	// good response closes the circuit, the next ban signal opens it again
+	StateHalfOpen State = "half_open"
+)
+
+// Event types published on Config.Channel
+const (
+	EventTripped = "breaker_tripped"
+	EventClosed  = "breaker_closed"
+)
+
+// ErrOpen is matched by errors.Is for requests rejected by an open circuit
+var ErrOpen = errors.New("circuit open")
+
+// OpenError reports a request rejected because its source is paused
+type OpenError struct {
+	Source string
+	Until  time.Time
+	Reason string
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s circuit open until %s (%s)", e.Source, e.Until.Format(time.RFC3339), e.Reason)
+}
+
+func (e *OpenError) Is(target error) bool {
+	return target == ErrOpen
+}
+
+// Config holds breaker options shared by every source
+type Config struct {
+	// Threshold is the number of consecutive ban signals (403/429, unexpected
+	// content type) that trips the circuit; challenge pages trip it at once
+	// (default 5)
+	Threshold int
+	// Cooldown is the first open period, doubled on every trip without a
+	// successful probe in between (default 5m)
+	Cooldown time.Duration
+	// MaxCooldown caps the open period (default 6h)
+	MaxCooldown time.Duration
+	// Prefix is the Redis key prefix for circuit state (default "breaker")
+	Prefix string
+	// Channel is the Redis pub/sub channel for trip/close events (default "crawler:events")
+	Channel string
+}
+
+// Status is the shared state of one source's circuit
+type Status struct {
+	Source    string    `json:"source"`
+	State     State     `json:"state"`
+	Trips     int       `json:"trips,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	TrippedAt time.Time `json:"tripped_at,omitzero"`
+	OpenUntil time.Time `json:"open_until,omitzero"`
+}
+
+// Event is published when a source trips or recovers
+type Event struct {
+	Type string `json:"type"`
+	Status
+	At time.Time `json:"at"`
+}
+
+// trip opens the circuit unless another process already did
+// Returns {1, trips, open_until} when this call tripped it, {0, trips, open_until} otherwise
+var trip = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local base = tonumber(ARGV[2])
+local max = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "state", "open_until", "trips")
+local trips = tonumber(state[3]) or 0
+if state[1] == "open" and tonumber(state[2]) > now then
+	return {0, trips, tonumber(state[2])}
+end
+
+trips = trips + 1
+local cooldown = math.min(base * math.pow(2, trips - 1), max)
+local open_until = now + cooldown
+redis.call("HSET", KEYS[1], "state", "open", "trips", trips, "reason", ARGV[4], "tripped_at", now, "open_until", open_until)
+redis.call("PEXPIRE", KEYS[1], cooldown + max)
+return {1, trips, open_until}
+`)
+
+// closeCircuit clears a half-open circuit; a circuit re-opened meanwhile is kept
+var closeCircuit = redis.NewScript(`
+local state = redis.call("HMGET", KEYS[1], "state", "open_until")
+if state[1] == "open" and tonumber(state[2]) <= tonumber(ARGV[1]) then
+	redis.call("DEL", KEYS[1])
+	return 1
+end
+return 0
+`)
+
+// Group hands out one breaker per source; state lives in Redis so the
+// crawler and the enricher of a source pause together
+type Group struct {
+	client *redis.Client
+	config Config
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewGroup creates the breakers for every source
+func NewGroup(client *redis.Client, cfg Config) *Group {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 5
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 5 * time.Minute
+	}
+	if cfg.MaxCooldown <= 0 {
+		cfg.MaxCooldown = 6 * time.Hour
+	}
+	cfg.MaxCooldown = max(cfg.MaxCooldown, cfg.Cooldown)
+	if cfg.Prefix == "" {
+		cfg.Prefix = "breaker"
+	}
+	if cfg.Channel == "" {
+		cfg.Channel = "crawler:events"
+	}
+	return &Group{client: client, config: cfg, breakers: make(map[string]*Breaker)}
+}
+
+// Breaker returns the source's breaker (nil for a nil group)
+func (g *Group) Breaker(source string) *Breaker {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	b, ok := g.breakers[source]
+	if !ok {
+		b = &Breaker{client: g.client, config: g.config, source: source}
+		g.breakers[source] = b
+	}
+	return b
+}
+
+// List returns the status of every source whose circuit is open or half-open
+func (g *Group) List(ctx context.Context) ([]Status, error) {
+	if g == nil {
+		return nil, nil
+	}
+	var statuses []Status
+	iter := g.client.Scan(ctx, 0, g.config.Prefix+":*", 100).Iterator()
+	for iter.Next(ctx) {
+		source := strings.TrimPrefix(iter.Val(), g.config.Prefix+":")
+		status, err := g.Breaker(source).Status(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if status.State != StateClosed {
+			statuses = append(statuses, status)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("scan breakers: %w", err)
+	}
+	return statuses, nil
+}
+
+// Breaker pauses one source after repeated ban signals
+// Methods are safe on a nil *Breaker (always closed)
+type Breaker struct {
+	client *redis.Client
+	config Config
+	source string
+
+	mu       sync.Mutex
+	failures int // Consecutive ban signals seen by this process
+}
+
+// Source returns the source the breaker guards
+func (b *Breaker) Source() string {
+	if b == nil {
+		return ""
+	}
+	return b.source
+}
+
+// Status reads the shared circuit state
+func (b *Breaker) Status(ctx context.Context) (Status, error) {
+	if b == nil {
+		return Status{State: StateClosed}, nil
+	}
+	status := Status{Source: b.source, State: StateClosed}
+
+	fields, err := b.client.HGetAll(ctx, b.key()).Result()
+	if err != nil {
+		return status, fmt.Errorf("read breaker %s: %w", b.source, err)
+	}
+	if fields["state"] != string(StateOpen) {
+		return status, nil
+	}
+
+	status.Trips, _ = strconv.Atoi(fields["trips"])
+	status.Reason = fields["reason"]
+	status.TrippedAt = unixMilli(fields["tripped_at"])
+	status.OpenUntil = unixMilli(fields["open_until"])
+	status.State = StateOpen
+	if !time.Now().Before(status.OpenUntil) {
+		status.State = StateHalfOpen
+	}
+	return status, nil
+}
+
+// Allow returns an *OpenError while the circuit is open
+// Redis failures let the request through
+func (b *Breaker) Allow(ctx context.Context) (Status, error) {
+	status, err := b.Status(ctx)
+	if err != nil {
+		log.Printf("[Breaker] %s: %v", b.source, err)
+		return status, nil
+	}
+	if status.State == StateOpen {
+		return status, &OpenError{Source: b.source, Until: status.OpenUntil, Reason: status.Reason}
+	}
+	return status, nil
+}
+
+// Wait blocks while the circuit is open, re-reading the state at least every
+// minute so a manual reset resumes work early
+func (b *Breaker) Wait(ctx context.Context) error {
+	for {
+		status, err := b.Allow(ctx)
+		if err == nil {
+			return nil
+		}
+		log.Printf("[Breaker] %s paused until %s (%s)", b.source, status.OpenUntil.Format(time.RFC3339), status.Reason)
+
+		timer := time.NewTimer(min(time.Until(status.OpenUntil), time.Minute))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Success records a good response; a half-open circuit closes
+func (b *Breaker) Success(ctx context.Context, seen Status) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.failures = 0
+	b.mu.Unlock()
+
+	if seen.State != StateHalfOpen {
+		return
+	}
+	closed, err := closeCircuit.Run(ctx, b.client, []string{b.key()}, time.Now().UnixMilli()).Int()
+	if err != nil {
+		log.Printf("[Breaker] %s: close circuit: %v", b.source, err)
+		return
+	}
+	if closed == 1 {
+		log.Printf("[Breaker] %s: probe succeeded, circuit closed", b.source)
+		b.publish(ctx, Event{Type: EventClosed, Status: Status{Source: b.source, State: StateClosed}})
+	}
+}
+
+// Failure records a ban signal; the circuit trips on a challenge page, a
+// failed half-open probe or Threshold consecutive signals
+func (b *Breaker) Failure(ctx context.Context, seen Status, signal Signal) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.failures++
+	failures := b.failures
+	tripNow := signal.Challenge || seen.State == StateHalfOpen || failures >= b.config.Threshold
+	if tripNow {
+		b.failures = 0
+	}
+	b.mu.Unlock()
+
+	if !tripNow {
+		log.Printf("[Breaker] %s: ban signal %d/%d: %s", b.source, failures, b.config.Threshold, signal.Reason)
+		return
+	}
+	b.Trip(ctx, signal.Reason)
+}
+
+// Trip opens the circuit for Cooldown * 2^(trips-1), capped at MaxCooldown,
+// and publishes an event unless another process tripped it first
+func (b *Breaker) Trip(ctx context.Context, reason string) {
+	if b == nil {
+		return
+	}
+	now := time.Now()
+	res, err := trip.Run(ctx, b.client, []string{b.key()},
+		now.UnixMilli(), b.config.Cooldown.Milliseconds(), b.config.MaxCooldown.Milliseconds(), reason).Int64Slice()
+	if err != nil {
+		log.Printf("[Breaker] %s: trip circuit: %v", b.source, err)
+		return
+	}
+	if res[0] == 0 {
+		return
+	}
+
+	status := Status{
+		Source:    b.source,
+		State:     StateOpen,
+		Trips:     int(res[1]),
+		Reason:    reason,
+		TrippedAt: now,
+		OpenUntil: time.UnixMilli(res[2]),
+	}
+	log.Printf("[Breaker] %s: circuit tripped (%s), trip %d, paused until %s", b.source, reason, status.Trips, status.OpenUntil.Format(time.RFC3339))
+	b.publish(ctx, Event{Type: EventTripped, Status: status})
+}
+
+// Reset closes the circuit and forgets its trip count
+func (b *Breaker) Reset(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	if err := b.client.Del(ctx, b.key()).Err(); err != nil {
+		return fmt.Errorf("reset breaker %s: %w", b.source, err)
+	}
+	return nil
+}
+
+// Transport rejects requests while the circuit is open and feeds every
+// response through Detect; block pages come back as a *BanError
+// Network errors are left to the retry layer and do not count as ban signals
+func (b *Breaker) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if b == nil {
+		return base
+	}
+	return &transport{base: base, breaker: b}
+}
+
+type transport struct {
+	base    http.RoundTripper
+	breaker *Breaker
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	seen, err := t.breaker.Allow(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// State writes must land even if the caller gives up on the request
+	ctx := context.WithoutCancel(req.Context())
+	body, err := peek(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	signal, banned := Detect(req, resp, body)
+	if !banned {
+		t.breaker.Success(ctx, seen)
+		return resp, nil
+	}
+
+	t.breaker.Failure(ctx, seen, signal)
+	if signal.Blocked {
+		resp.Body.Close()
+		return nil, &BanError{URL: req.URL.String(), Reason: signal.Reason}
+	}
+	return resp, nil
+}
+
+func (b *Breaker) publish(ctx context.Context, event Event) {
+	event.At = time.Now()
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[Breaker] %s: marshal event: %v", b.source, err)
+		return
+	}
+	if err := b.client.Publish(ctx, b.config.Channel, data).Err(); err != nil {
+		log.Printf("[Breaker] %s: publish event: %v", b.source, err)
+	}
+}
+
+func (b *Breaker) key() string {
+	return b.config.Prefix + ":" + b.source
+}
+
+func unixMilli(s string) time.Time {
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
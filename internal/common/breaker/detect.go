@@ -0,0 +1,172 @@
+package breaker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// peekSize is how much of an HTML body is scanned for challenge markers
+const peekSize = 64 << 10
+
+// ErrBanned is matched by errors.Is for responses replaced by a block page
+var ErrBanned = errors.New("blocked by site")
+
+// BanError reports a challenge page or a block page served in place of the
+// expected content
+type BanError struct {
+	URL    string
+	Reason string
+}
+
+func (e *BanError) Error() string {
+	return fmt.Sprintf("%s blocked: %s", e.URL, e.Reason)
+}
+
+func (e *BanError) Is(target error) bool {
+	return target == ErrBanned
+}
+
+// Signal is a sign that a site is blocking us
+type Signal struct {
+	Reason string
+	// Challenge marks an interstitial (JS challenge, captcha) that trips the
+	// circuit at once instead of counting towards the threshold
+	Challenge bool
+	// Blocked means the body is not the requested content, so the response
+	// is turned into a *BanError
+	Blocked bool
+}
+
+// challengeMarkers identify anti-bot interstitials on any status
+var challengeMarkers = []struct{ marker, name string }{
+	{"cf-browser-verification", "cloudflare challenge"},
+	{"cf_chl_opt", "cloudflare challenge"},
+	{"<title>just a moment...</title>", "cloudflare challenge"},
+	{"attention required! | cloudflare", "cloudflare block"},
+	{"captcha-delivery.com", "datadome captcha"},
+	{"_incapsula_resource", "incapsula challenge"},
+	{"px-captcha", "perimeterx captcha"},
+}
+
+// errorMarkers only count on error statuses: normal pages embed captcha
+// widgets (apply forms) and Cloudflare's bot-detection script
+var errorMarkers = []struct{ marker, name string }{
+	{"/cdn-cgi/challenge-platform/", "cloudflare challenge"},
+	{"captcha", "captcha"},
+}
+
+// Detect looks for ban signals in a response; body is the start of the
+// decoded body (only needed for HTML responses)
+// It returns false for responses that look like normal content
+func Detect(req *http.Request, resp *http.Response, body []byte) (Signal, bool) {
+	if resp.Header.Get("cf-mitigated") == "challenge" {
+		return Signal{Reason: "cloudflare challenge", Challenge: true, Blocked: true}, true
+	}
+
+	mediaType := contentType(resp)
+	if mediaType == "text/html" && len(body) > 0 {
+		lower := bytes.ToLower(body)
+		for _, m := range challengeMarkers {
+			if bytes.Contains(lower, []byte(m.marker)) {
+				return Signal{Reason: m.name, Challenge: true, Blocked: true}, true
+			}
+		}
+		if resp.StatusCode >= 400 {
+			for _, m := range errorMarkers {
+				if bytes.Contains(lower, []byte(m.marker)) {
+					return Signal{Reason: fmt.Sprintf("%s (status %d)", m.name, resp.StatusCode), Challenge: true, Blocked: true}, true
+				}
+			}
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		// The API rejected the caller's token, which it refreshes itself
+		if tokenAuth(req.Context()) {
+			return Signal{}, false
+		}
+	}
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		return Signal{Reason: fmt.Sprintf("status %d", resp.StatusCode)}, true
+	}
+
+	// An API answering with an HTML page is a block page, not data
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 && mediaType != "" && !accepts(req.Header.Get("Accept"), mediaType) {
+		return Signal{Reason: "unexpected content type " + mediaType, Blocked: true}, true
+	}
+	return Signal{}, false
+}
+
+type tokenAuthKey struct{}
+
+// WithTokenAuth marks requests made with ctx as carrying a token the caller
+// refreshes on 401/403; those statuses then mean a rejected token, not a ban
+// Challenge pages still count
+func WithTokenAuth(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tokenAuthKey{}, true)
+}
+
+func tokenAuth(ctx context.Context) bool {
+	auth, _ := ctx.Value(tokenAuthKey{}).(bool)
+	return auth
+}
+
+// peek reads the start of an HTML body and puts it back in front of the rest
+func peek(resp *http.Response) ([]byte, error) {
+	if contentType(resp) != "text/html" || resp.Header.Get("Content-Encoding") != "" {
+		return nil, nil
+	}
+	head, err := io.ReadAll(io.LimitReader(resp.Body, peekSize))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &peekedBody{Reader: io.MultiReader(bytes.NewReader(head), resp.Body), raw: resp.Body}
+	return head, nil
+}
+
+type peekedBody struct {
+	io.Reader
+	raw io.ReadCloser
+}
+
+func (b *peekedBody) Close() error {
+	return b.raw.Close()
+}
+
+// contentType returns the response media type in lower case ("" when absent)
+func contentType(resp *http.Response) string {
+	value := resp.Header.Get("Content-Type")
+	if value == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// accepts reports whether an Accept header allows mediaType
+// An empty header accepts everything
+func accepts(accept, mediaType string) bool {
+	if strings.TrimSpace(accept) == "" {
+		return true
+	}
+	major, _, _ := strings.Cut(mediaType, "/")
+	for _, part := range strings.Split(accept, ",") {
+		want, _, _ := strings.Cut(part, ";")
+		want = strings.ToLower(strings.TrimSpace(want))
+		if want == "*/*" || want == mediaType || want == major+"/*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,505 @@
+package cassette
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Mode selects whether HTTP traffic goes to the network, a cassette, or both
+type Mode string
+
+const (
+	// ModeOff sends requests to the network only (default)
+	ModeOff Mode = "off"
+	// ModeRecord sends requests to the network and writes every exchange to a cassette
+	ModeRecord Mode = "record"
+	// ModeReplay answers requests from a cassette without touching the network
+	ModeReplay Mode = "replay"
+)
+
+// Redacted replaces secret header, query and body values in cassettes
+const Redacted = "REDACTED"
+
+// ErrNoInteraction is returned in replay mode when no recorded exchange matches
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// Config holds recorder options
+type Config struct {
+	Mode Mode
+	// Dir holds one <name>.jsonl cassette per source (default testdata/cassettes)
+	Dir string
+	// IgnoreParams are volatile query params left out of request matching
+	// (cache busters, timestamps)
+	IgnoreParams []string
+	// RedactHeaders are request and response headers written as REDACTED
+	// (Authorization carries the vieclam24h token, Set-Cookie the sessions)
+	RedactHeaders []string
+	// RedactParams are query params written as REDACTED
+	RedactParams []string
+	// RedactFields are JSON body fields (at any depth) whose values are
+	// written as REDACTED, in requests and responses; JWTs in text bodies
+	// (e.g. the vieclam24h bootstrap page) are always redacted
+	RedactFields []string
+}
+
+func (c Config) withDefaults() Config {
+	if c.Dir == "" {
+		c.Dir = filepath.Join("testdata", "cassettes")
+	}
+	if c.IgnoreParams == nil {
+		c.IgnoreParams = []string{"_", "t", "ts", "timestamp", "nonce", "rand", "cb"}
+	}
+	if c.RedactHeaders == nil {
+		c.RedactHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "Proxy-Authorization"}
+	}
+	if c.RedactParams == nil {
+		c.RedactParams = []string{"token", "access_token", "api_key", "apikey", "key"}
+	}
+	if c.RedactFields == nil {
+		c.RedactFields = []string{"token", "access_token", "refresh_token", "id_token", "api_key", "password", "client_secret"}
+	}
+	return c
+}
+
+// Interaction is one recorded request/response pair
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the recorded (redacted) request
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    Body        `json:"body,omitempty"`
+}
+
+// Response is the recorded response
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       Body        `json:"body,omitempty"`
+}
+
+// Body stores text as-is and binary (e.g. gzip) payloads as base64
+type Body []byte
+
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*b = Body(text)
+		return nil
+	}
+	var encoded struct {
+		Base64 string `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return fmt.Errorf("decode body: %w", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded.Base64)
+	if err != nil {
+		return fmt.Errorf("decode body: %w", err)
+	}
+	*b = raw
+	return nil
+}
+
+// Recorder hands out one cassette per source
+type Recorder struct {
+	config    Config
+	mu        sync.Mutex
+	cassettes map[string]*Cassette
+}
+
+// NewRecorder creates a recorder; it returns nil when the mode is off
+func NewRecorder(cfg Config) *Recorder {
+	if cfg.Mode != ModeRecord && cfg.Mode != ModeReplay {
+		return nil
+	}
+	cfg = cfg.withDefaults()
+	log.Printf("[Cassette] HTTP %s mode, cassettes in %s", cfg.Mode, cfg.Dir)
+	return &Recorder{config: cfg, cassettes: make(map[string]*Cassette)}
+}
+
+// Cassette returns the cassette for a source, shared by all its fetchers
+// Recording truncates the file on first use; a missing file in replay mode
+// makes every request fail with ErrNoInteraction
+func (r *Recorder) Cassette(name string) *Cassette {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.cassettes[name]; ok {
+		return c
+	}
+
+	path := filepath.Join(r.config.Dir, name+".jsonl")
+	c := &Cassette{path: path, mode: r.config.Mode, config: r.config}
+	switch r.config.Mode {
+	case ModeRecord:
+		if err := os.MkdirAll(r.config.Dir, 0o755); err != nil {
+			log.Printf("[Cassette] %s: %v", name, err)
+		} else if err := os.WriteFile(path, nil, 0o644); err != nil {
+			log.Printf("[Cassette] %s: %v", name, err)
+		}
+	case ModeReplay:
+		if err := c.load(); err != nil {
+			log.Printf("[Cassette] %s: %v", name, err)
+		}
+	}
+	r.cassettes[name] = c
+	return c
+}
+
+// Cassette is a list of recorded interactions backed by a JSON Lines file
+type Cassette struct {
+	path   string
+	mode   Mode
+	config Config
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// Load opens a cassette file for replay, e.g. as a test fixture
+func Load(path string) (*Cassette, error) {
+	c := &Cassette{path: path, mode: ModeReplay, config: Config{}.withDefaults()}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Cassette) load() error {
+	f, err := os.Open(c.path)
+	if err != nil {
+		return fmt.Errorf("open cassette: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 1<<20), 64<<20)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var it Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &it); err != nil {
+			return fmt.Errorf("decode cassette %s: %w", c.path, err)
+		}
+		c.interactions = append(c.interactions, it)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read cassette %s: %w", c.path, err)
+	}
+	c.used = make([]bool, len(c.interactions))
+	return nil
+}
+
+// Replaying reports whether requests are answered from the cassette
+func (c *Cassette) Replaying() bool {
+	return c != nil && c.mode == ModeReplay
+}
+
+// Interactions returns the recorded exchanges
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Interaction(nil), c.interactions...)
+}
+
+// Client returns an HTTP client that replays or records through the cassette
+func (c *Cassette) Client() *http.Client {
+	return &http.Client{Transport: c.Transport(nil)}
+}
+
+// Transport replays from the cassette, or records what base returns
+func (c *Cassette) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if c == nil {
+		return base
+	}
+	return &transport{base: base, cassette: c}
+}
+
+type transport struct {
+	base     http.RoundTripper
+	cassette *Cassette
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.cassette.Replaying() {
+		return t.cassette.replay(req, body)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	if err := t.cassette.record(req, body, resp, respBody); err != nil {
+		log.Printf("[Cassette] Record %s: %v", req.URL.Redacted(), err)
+	}
+	return resp, nil
+}
+
+// replay answers with the first unused matching interaction; once all
+// matches are used the last one is repeated
+func (c *Cassette) replay(req *http.Request, body []byte) (*http.Response, error) {
+	key := c.config.matchKey(req.Method, req.URL.String(), body)
+
+	c.mu.Lock()
+	found := -1
+	for i, it := range c.interactions {
+		if c.config.matchKey(it.Request.Method, it.Request.URL, it.Request.Body) != key {
+			continue
+		}
+		found = i
+		if !c.used[i] {
+			break
+		}
+	}
+	if found >= 0 {
+		c.used[found] = true
+	}
+	c.mu.Unlock()
+
+	if found < 0 {
+		return nil, fmt.Errorf("%w for %s %s in %s", ErrNoInteraction, req.Method, req.URL.Redacted(), c.path)
+	}
+
+	rec := c.interactions[found].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.StatusCode, http.StatusText(rec.StatusCode)),
+		StatusCode:    rec.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Headers.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+// record appends one redacted interaction to the cassette file
+func (c *Cassette) record(req *http.Request, body []byte, resp *http.Response, respBody []byte) error {
+	it := Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     c.config.redactURL(req.URL),
+			Headers: c.config.redactHeaders(req.Header),
+			Body:    c.config.redactBody(body),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    c.config.redactHeaders(resp.Header),
+			Body:       c.config.redactBody(respBody),
+		},
+	}
+	line, err := json.Marshal(it)
+	if err != nil {
+		return fmt.Errorf("encode interaction: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, it)
+	c.used = append(c.used, false)
+
+	f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open cassette: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write cassette: %w", err)
+	}
+	return nil
+}
+
+// matchKey identifies a request by method, URL without volatile params
+// (query sorted) and body (redacted, JSON canonicalized)
+func (c Config) matchKey(method, rawURL string, body []byte) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return method + " " + rawURL
+	}
+	query := u.Query()
+	for _, p := range c.IgnoreParams {
+		query.Del(p)
+	}
+	for _, p := range c.RedactParams {
+		if query.Has(p) {
+			query.Set(p, Redacted)
+		}
+	}
+	u.RawQuery = query.Encode() // Encode sorts by key
+	u.Fragment = ""
+
+	body = c.redactBody(body)
+	var parsed any
+	if json.Unmarshal(body, &parsed) == nil {
+		if canonical, err := json.Marshal(parsed); err == nil {
+			body = canonical
+		}
+	}
+	return method + " " + u.String() + "\n" + string(body)
+}
+
+func (c Config) redactURL(u *url.URL) string {
+	clean := *u
+	clean.User = nil
+	query := clean.Query()
+	changed := false
+	for _, p := range c.RedactParams {
+		if query.Has(p) {
+			query.Set(p, Redacted)
+			changed = true
+		}
+	}
+	if changed {
+		clean.RawQuery = query.Encode()
+	}
+	return clean.String()
+}
+
+// redactHeaders replaces every value of the secret headers
+func (c Config) redactHeaders(h http.Header) http.Header {
+	clean := h.Clone()
+	for _, name := range c.RedactHeaders {
+		if values := clean.Values(name); len(values) > 0 {
+			redacted := make([]string, len(values))
+			for i := range redacted {
+				redacted[i] = Redacted
+			}
+			clean[http.CanonicalHeaderKey(name)] = redacted
+		}
+	}
+	if len(clean) == 0 {
+		return nil
+	}
+	return clean
+}
+
+// jwtPattern matches JSON Web Tokens (header.payload.signature)
+var jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+
+// redactBody replaces secret JSON fields and JWTs in a text body; binary
+// (compressed) bodies are kept as is
+func (c Config) redactBody(body []byte) []byte {
+	if len(body) == 0 || !utf8.Valid(body) {
+		return body
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var parsed any
+	if len(c.RedactFields) > 0 && dec.Decode(&parsed) == nil && c.redactFields(parsed) {
+		if redacted, err := json.Marshal(parsed); err == nil {
+			body = redacted
+		}
+	}
+	return jwtPattern.ReplaceAll(body, []byte(Redacted))
+}
+
+// redactFields replaces secret fields in decoded JSON and reports whether any was found
+func (c Config) redactFields(v any) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if c.secretField(key) {
+				if value != nil && value != Redacted {
+					v[key] = Redacted
+					changed = true
+				}
+				continue
+			}
+			changed = c.redactFields(value) || changed
+		}
+	case []any:
+		for _, item := range v {
+			changed = c.redactFields(item) || changed
+		}
+	}
+	return changed
+}
+
+func (c Config) secretField(key string) bool {
+	for _, name := range c.RedactFields {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// readBody buffers the request body and restores it for the next transport
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// Names lists the cassettes in dir, e.g. to run one test per recorded source
+func Names(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read cassette dir: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".jsonl"); ok && !e.IsDir() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,39 @@
+// Package dateutil parses the dates found in job source payloads; it is a
+// leaf package shared by the extractors, crawlers and the normalizer
+package dateutil
+
+import (
+	"strings"
+	"time"
+)
+
+// location is Vietnam time (UTC+7, no DST), where every source publishes;
+// dates without a zone are read in it whatever the host TZ
+var location = time.FixedZone("ICT", 7*60*60)
+
+// formats are the date layouts used across job sources
+var formats = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"02/01/2006",
+	"02-01-2006",
+}
+
+// Parse parses the date formats used across job sources (ISO dates and
+// timestamps, dd/mm/yyyy, dd-mm-yyyy) in Vietnam time, returning zero time
+// if none match
+func Parse(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, format := range formats {
+		if t, err := time.ParseInLocation(format, s, location); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Unix returns the Unix timestamp sec in Vietnam time
+func Unix(sec int64) time.Time {
+	return time.Unix(sec, 0).In(location)
+}
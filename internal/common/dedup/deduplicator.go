@@ -88,6 +88,18 @@ func (d *Deduplicator) MarkSeenWithTTL(ctx context.Context, source, jobID, lastU
 	return nil
 }
 
+// Marker returns the change-detection value stored for a job, empty when unseen
+func (d *Deduplicator) Marker(ctx context.Context, source, jobID string) (string, error) {
+	value, err := d.client.Get(ctx, d.makeKey(source, jobID)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("redis get: %w", err)
+	}
+	return value, nil
+}
+
 // IsSeen checks if a job URL/ID has been seen before (legacy method)
 func (d *Deduplicator) IsSeen(ctx context.Context, source, jobID string) (bool, error) {
 	key := d.makeKey(source, jobID)
@@ -120,6 +132,17 @@ func (d *Deduplicator) MarkSeenByContent(ctx context.Context, source, content st
 	return d.MarkSeen(ctx, source, "content:"+hash)
 }
 
+// Fingerprint returns a stable content hash for change detection
+// Used by sources whose API has no reliable updated_at field
+func Fingerprint(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0}) // Separator so ("ab","c") != ("a","bc")
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8]) // First 8 bytes (16 hex chars)
+}
+
 func (d *Deduplicator) makeKey(source, id string) string {
 	return fmt.Sprintf("%s:%s:%s", d.prefix, source, id)
 }
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/project-tktt/go-crawler/internal/common/fetch"
 	"github.com/project-tktt/go-crawler/internal/domain"
 )
 
@@ -22,14 +23,9 @@ type APIExtractor struct {
 
 // NewAPIExtractor creates a new API-based extractor
 func NewAPIExtractor(source domain.JobSource, baseURL string, config ExtractorConfig) *APIExtractor {
-	transport := &http.Transport{}
-
-	// Note: Proxy configuration would be added here if ProxyURL is set
-	// For residential proxy support (VietnamWorks)
-
 	client := &http.Client{
 		Timeout:   30 * time.Second,
-		Transport: transport,
+		Transport: httpOptions(config).Transport(&http.Transport{}),
 	}
 
 	return &APIExtractor{
@@ -63,8 +59,8 @@ func (e *APIExtractor) Extract(ctx context.Context, url string) (*domain.RawJob,
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	if err := fetch.CheckResponse(resp); err != nil {
+		return nil, err
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -101,8 +97,8 @@ func (e *APIExtractor) ExtractList(ctx context.Context, listURL string, page int
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	if err := fetch.CheckResponse(resp); err != nil {
+		return nil, err
 	}
 
 	body, err := io.ReadAll(resp.Body)
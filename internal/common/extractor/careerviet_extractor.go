@@ -14,6 +14,8 @@ import (
 	"time"
 
 	"github.com/gocolly/colly/v2"
+	"github.com/project-tktt/go-crawler/internal/common/dateutil"
+	"github.com/project-tktt/go-crawler/internal/common/fetch"
 	"github.com/project-tktt/go-crawler/internal/domain"
 )
 
@@ -70,10 +72,14 @@ func NewCareerVietExtractor(config ExtractorConfig) *CareerVietExtractor {
 		IdleConnTimeout:       90 * time.Second,
 	}
 
+	// Both Colly (HTML) and the API client share the per-host rate limit
+	opts := httpOptions(config)
+	c.WithTransport(opts.Transport(transport))
+
 	client := &http.Client{
 		Timeout:   60 * time.Second,
 		Jar:       jar, // Share the same cookie jar
-		Transport: transport,
+		Transport: opts.Transport(transport),
 	}
 
 	return &CareerVietExtractor{
@@ -115,6 +121,18 @@ func (e *CareerVietExtractor) Extract(ctx context.Context, jobURL string) (*doma
 		rawData["experience"] = strings.TrimSpace(el.Text)
 	})
 
+	// Update date ("Ngày cập nhật") and application deadline ("Hết hạn nộp")
+	// from the detail box
+	c.OnHTML(".detail-box li", func(el *colly.HTMLElement) {
+		label := el.ChildText("strong")
+		switch {
+		case strings.Contains(label, "Ngày cập nhật"):
+			rawData["updated"] = strings.TrimSpace(el.ChildText("p"))
+		case strings.Contains(label, "Hết hạn nộp"):
+			rawData["expire"] = strings.TrimSpace(el.ChildText("p"))
+		}
+	})
+
 	c.OnHTML(".content-group .job-tag, .content-group__tag", func(el *colly.HTMLElement) {
 		benefits := rawData["benefits"]
 		if benefits == nil {
@@ -138,12 +156,17 @@ func (e *CareerVietExtractor) Extract(ctx context.Context, jobURL string) (*doma
 	// Extract job ID from URL
 	jobID := extractJobID(jobURL)
 
+	updated, _ := rawData["updated"].(string)
+	expire, _ := rawData["expire"].(string)
+
 	return &domain.RawJob{
-		ID:          jobID,
-		URL:         jobURL,
-		Source:      string(domain.SourceCareerViet),
-		RawData:     rawData,
-		ExtractedAt: time.Now(),
+		ID:            jobID,
+		URL:           jobURL,
+		Source:        string(domain.SourceCareerViet),
+		RawData:       rawData,
+		LastUpdatedOn: updated,
+		ExpiredOn:     dateutil.Parse(expire),
+		ExtractedAt:   time.Now(),
 	}, nil
 }
 
@@ -185,6 +208,7 @@ func (e *CareerVietExtractor) extractFromHTML(ctx context.Context, listURL strin
 		link := el.ChildAttr(".job_link", "href")
 		salary := strings.TrimSpace(el.ChildText(".salary"))
 		location := strings.TrimSpace(el.ChildText(".location"))
+		updated := strings.TrimSpace(el.ChildText(".time time")) // Update date (dd-mm-yyyy)
 
 		if link == "" {
 			return
@@ -206,6 +230,7 @@ func (e *CareerVietExtractor) extractFromHTML(ctx context.Context, listURL strin
 				"company":  company,
 				"salary":   salary,
 				"location": location,
+				"updated":  updated,
 				"page":     page,
 				"source":   "html",
 			},
@@ -251,8 +276,8 @@ func (e *CareerVietExtractor) extractFromAPI(ctx context.Context, page int) ([]*
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	if err := fetch.CheckResponse(resp); err != nil {
+		return nil, err
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -272,9 +297,10 @@ func (e *CareerVietExtractor) extractFromAPI(ctx context.Context, page int) ([]*
 		}
 
 		jobs = append(jobs, &domain.RawJob{
-			ID:     job.JobID,
-			URL:    job.JobLink,
-			Source: string(domain.SourceCareerViet),
+			ID:        job.JobID,
+			URL:       job.JobLink,
+			Source:    string(domain.SourceCareerViet),
+			ExpiredOn: dateutil.Parse(job.ExpireDate), // For TTL calculation
 			RawData: map[string]any{
 				"title":     job.JobTitle,
 				"company":   job.CompanyName,
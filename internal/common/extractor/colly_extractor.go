@@ -3,10 +3,13 @@ package extractor
 import (
 	"context"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
 	"github.com/gocolly/colly/v2"
+	"github.com/project-tktt/go-crawler/internal/common/fetch"
+	"github.com/project-tktt/go-crawler/internal/common/proxy"
 	"github.com/project-tktt/go-crawler/internal/domain"
 )
 
@@ -21,8 +24,14 @@ type CollyExtractor struct {
 // Selectors defines CSS selectors for extracting job data
 type Selectors struct {
 	// List page selectors
-	JobItem string
-	JobLink string
+	JobItem     string
+	JobLink     string
+	JobLinkAttr string // Attribute holding the job URL (default href)
+
+	// Pagination: FirstPageURL for page 1, PageURLPattern with {page} for the rest
+	// When both are empty, listURL?page=N is used
+	FirstPageURL   string
+	PageURLPattern string
 
 	// Detail page selectors
 	Title        string
@@ -38,6 +47,14 @@ type Selectors struct {
 
 	// For Next.js sites (TopCV) - extract from script tag
 	NextDataScript string
+
+	// Declarative field rules (from a SiteDefinition); replace the fixed
+	// detail selectors above when set
+	Fields []FieldRule
+
+	// JSONLD reads the detail page from its schema.org JobPosting JSON-LD;
+	// Fields/selectors are only used when the page has none
+	JSONLD bool
 }
 
 // NewCollyExtractor creates a new Colly-based HTML scraper
@@ -56,10 +73,9 @@ func NewCollyExtractor(source domain.JobSource, selectors Selectors, config Extr
 		})
 	}
 
-	// Set proxy if configured
-	if config.ProxyURL != "" {
-		c.SetProxy(config.ProxyURL)
-	}
+	// Retries, shared per-host rate limit and the proxy pool
+	// (colly.SetProxy would replace this transport, so proxies live in it)
+	c.WithTransport(httpOptions(config).Transport(nil))
 
 	return &CollyExtractor{
 		collector: c,
@@ -93,10 +109,39 @@ func (e *CollyExtractor) Extract(ctx context.Context, url string) (*domain.RawJo
 		})
 	}
 
+	// Handle schema.org JobPosting JSON-LD (usually in <head>)
+	if e.selectors.JSONLD {
+		collector.OnHTML("html", func(el *colly.HTMLElement) {
+			if rawJob != nil {
+				return
+			}
+			postings := ExtractJobPostings(el.DOM)
+			if len(postings) == 0 {
+				return
+			}
+			rawJob = &domain.RawJob{
+				URL:         url,
+				Source:      string(e.source),
+				RawData:     JobPostingRawData(postings[0]),
+				ExtractedAt: time.Now(),
+			}
+		})
+	}
+
 	// Standard HTML extraction
 	collector.OnHTML("body", func(el *colly.HTMLElement) {
 		if rawJob != nil {
-			return // Already extracted from __NEXT_DATA__
+			return // Already extracted from __NEXT_DATA__ or JSON-LD
+		}
+
+		if len(e.selectors.Fields) > 0 {
+			rawJob = &domain.RawJob{
+				URL:         url,
+				Source:      string(e.source),
+				RawData:     ApplyFields(el.DOM, e.selectors.Fields),
+				ExtractedAt: time.Now(),
+			}
+			return
 		}
 
 		rawData := make(map[string]any)
@@ -144,7 +189,7 @@ func (e *CollyExtractor) Extract(ctx context.Context, url string) (*domain.RawJo
 	})
 
 	collector.OnError(func(r *colly.Response, err error) {
-		extractErr = fmt.Errorf("colly error: %w (status: %d)", err, r.StatusCode)
+		extractErr = collyError(r, err)
 	})
 
 	if err := collector.Visit(url); err != nil {
@@ -168,10 +213,18 @@ func (e *CollyExtractor) ExtractList(ctx context.Context, listURL string, page i
 
 	collector := e.collector.Clone()
 
+	linkAttr := e.selectors.JobLinkAttr
+	if linkAttr == "" {
+		linkAttr = "href"
+	}
+
 	collector.OnHTML(e.selectors.JobItem, func(el *colly.HTMLElement) {
-		link := el.ChildAttr(e.selectors.JobLink, "href")
+		link := el.ChildAttr(e.selectors.JobLink, linkAttr)
+		if link == "" {
+			link = el.Attr(linkAttr)
+		}
 		if link == "" {
-			link = el.Attr("href")
+			return
 		}
 
 		// Make absolute URL if needed
@@ -187,10 +240,10 @@ func (e *CollyExtractor) ExtractList(ctx context.Context, listURL string, page i
 	})
 
 	collector.OnError(func(r *colly.Response, err error) {
-		extractErr = fmt.Errorf("colly error: %w", err)
+		extractErr = collyError(r, err)
 	})
 
-	url := fmt.Sprintf("%s?page=%d", listURL, page)
+	url := e.selectors.pageURL(listURL, page)
 	if err := collector.Visit(url); err != nil {
 		return nil, fmt.Errorf("visit list url: %w", err)
 	}
@@ -201,3 +254,28 @@ func (e *CollyExtractor) ExtractList(ctx context.Context, listURL string, page i
 
 	return jobs, nil
 }
+
+// httpOptions fills the fetch layers from the legacy MaxRetries/ProxyURL fields
+// when HTTP does not set them
+func httpOptions(config ExtractorConfig) fetch.Options {
+	opts := config.HTTP
+	if opts.Retry.MaxRetries == 0 {
+		opts.Retry.MaxRetries = config.MaxRetries
+	}
+	if opts.Proxies == nil && config.ProxyURL != "" {
+		pool, err := proxy.Load(proxy.Config{URLs: []string{config.ProxyURL}})
+		if err != nil {
+			log.Printf("[Extractor] Ignoring proxy: %v", err)
+		}
+		opts.Proxies = pool
+	}
+	return opts
+}
+
+// collyError wraps a Colly failure so fetch.IsRetryable can classify it
+func collyError(r *colly.Response, err error) error {
+	if r != nil && r.StatusCode >= 300 {
+		return fmt.Errorf("colly error: %w", &fetch.StatusError{StatusCode: r.StatusCode, URL: r.Request.URL.String()})
+	}
+	return fmt.Errorf("colly error: %w", err)
+}
@@ -3,6 +3,8 @@ package extractor
 import (
 	"context"
 
+	"github.com/project-tktt/go-crawler/internal/common/fetch"
+
 	"github.com/project-tktt/go-crawler/internal/domain"
 )
 
@@ -24,5 +26,6 @@ type ExtractorConfig struct {
 	UserAgent    string
 	ProxyURL     string
 	MaxRetries   int
-	RequestDelay int // milliseconds
+	RequestDelay int           // milliseconds
+	HTTP         fetch.Options // Shared rate limit, retry and proxy layers
 }
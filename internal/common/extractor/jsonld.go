@@ -0,0 +1,281 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/project-tktt/go-crawler/internal/domain"
+)
+
+// ExtractJobPostingsHTML parses an HTML page and returns every schema.org
+// JobPosting node found in its JSON-LD scripts
+func ExtractJobPostingsHTML(html string) ([]map[string]any, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+	return ExtractJobPostings(doc.Selection), nil
+}
+
+// ExtractJobPostings returns every JobPosting node in the JSON-LD scripts under root
+// Handles single objects, top-level arrays and @graph wrappers
+func ExtractJobPostings(root *goquery.Selection) []map[string]any {
+	var postings []map[string]any
+
+	root.Find("script[type='application/ld+json']").Each(func(_ int, sel *goquery.Selection) {
+		content := strings.TrimSpace(sel.Text())
+		if content == "" {
+			return
+		}
+
+		var data any
+		if err := json.Unmarshal([]byte(content), &data); err != nil {
+			// Some sites emit raw newlines/tabs inside strings; retry once without them
+			cleaned := strings.NewReplacer("\r", " ", "\n", " ", "\t", " ").Replace(content)
+			if err := json.Unmarshal([]byte(cleaned), &data); err != nil {
+				return
+			}
+		}
+		postings = append(postings, collectJobPostings(data)...)
+	})
+
+	return postings
+}
+
+// collectJobPostings walks arrays and @graph wrappers looking for JobPosting nodes
+func collectJobPostings(data any) []map[string]any {
+	switch v := data.(type) {
+	case []any:
+		var result []map[string]any
+		for _, item := range v {
+			result = append(result, collectJobPostings(item)...)
+		}
+		return result
+	case map[string]any:
+		if isJobPosting(v) {
+			return []map[string]any{v}
+		}
+		if graph, ok := v["@graph"]; ok {
+			return collectJobPostings(graph)
+		}
+	}
+	return nil
+}
+
+// isJobPosting checks @type, which may be a string or an array of strings
+func isJobPosting(node map[string]any) bool {
+	switch t := node["@type"].(type) {
+	case string:
+		return t == "JobPosting"
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok && s == "JobPosting" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// JobPostingRawData converts a JobPosting node to the standard RawData layout
+// read by normalizer.normalizeJobPosting
+func JobPostingRawData(node map[string]any) map[string]any {
+	rawData := map[string]any{
+		domain.RawDataLayoutKey: domain.LayoutJobPosting,
+		// Basic info
+		"title":       ldString(node["title"]),
+		"description": ldString(node["description"]),
+		"identifier":  ldIdentifier(node["identifier"]),
+		// Content
+		"jobBenefits":            ldString(node["jobBenefits"]),
+		"skills":                 ldString(node["skills"]),
+		"qualifications":         ldString(node["qualifications"]),
+		"responsibilities":       ldString(node["responsibilities"]),
+		"experienceRequirements": ldExperience(node["experienceRequirements"]),
+		// Classification
+		"employmentType":       strings.Join(ldStrings(node["employmentType"]), ", "),
+		"industry":             ldSplit(node["industry"]),
+		"occupationalCategory": ldString(node["occupationalCategory"]),
+		"totalJobOpenings":     ldInt(node["totalJobOpenings"]),
+		// Dates
+		"datePosted":   ldString(node["datePosted"]),
+		"validThrough": ldString(node["validThrough"]),
+	}
+
+	// Hiring organization: object or plain name
+	switch org := node["hiringOrganization"].(type) {
+	case string:
+		rawData["companyName"] = org
+	case map[string]any:
+		rawData["companyName"] = ldString(org["name"])
+		rawData["companyWebsite"] = ldString(org["sameAs"])
+		if rawData["companyWebsite"] == "" {
+			rawData["companyWebsite"] = ldString(org["url"])
+		}
+		rawData["companyLogo"] = ldString(org["logo"])
+	}
+
+	// Locations: object or array, address object or string
+	var cities, districts, streets []string
+	for _, loc := range ldList(node["jobLocation"]) {
+		locMap, ok := loc.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch addr := locMap["address"].(type) {
+		case string:
+			streets = appendUnique(streets, strings.TrimSpace(addr))
+		case map[string]any:
+			streets = appendUnique(streets, ldString(addr["streetAddress"]))
+			cities = appendUnique(cities, ldString(addr["addressRegion"]))
+			districts = appendUnique(districts, ldString(addr["addressLocality"]))
+		}
+	}
+	rawData["locationCity"] = cities
+	rawData["locationDistrict"] = districts
+	rawData["streetAddress"] = streets
+
+	// Salary: baseSalary.value may be a QuantitativeValue, a number or a text
+	if salary, ok := node["baseSalary"].(map[string]any); ok {
+		rawData["salaryCurrency"] = ldString(salary["currency"])
+		switch value := salary["value"].(type) {
+		case map[string]any:
+			rawData["salaryMin"] = ldInt(value["minValue"])
+			rawData["salaryMax"] = ldInt(value["maxValue"])
+			rawData["salaryUnit"] = ldString(value["unitText"])
+			if n := ldInt(value["value"]); n > 0 {
+				rawData["salaryMin"], rawData["salaryMax"] = n, n
+			} else if text := ldString(value["value"]); text != "" {
+				rawData["salaryText"] = text
+			}
+		case float64:
+			rawData["salaryMin"], rawData["salaryMax"] = int(value), int(value)
+		case string:
+			if n := ldInt(value); n > 0 {
+				rawData["salaryMin"], rawData["salaryMax"] = n, n
+			} else {
+				rawData["salaryText"] = value
+			}
+		}
+	}
+
+	return rawData
+}
+
+// ldString converts a JSON-LD scalar (or {"name": ...} object) to a trimmed string
+func ldString(val any) string {
+	switch v := val.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any:
+		if s := ldString(v["name"]); s != "" {
+			return s
+		}
+		if s := ldString(v["url"]); s != "" {
+			return s
+		}
+		return ldString(v["@id"])
+	case []any:
+		return strings.Join(ldStrings(v), ", ")
+	}
+	return ""
+}
+
+// ldStrings converts a scalar or array to a string slice
+func ldStrings(val any) []string {
+	var result []string
+	for _, item := range ldList(val) {
+		if s := ldString(item); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// ldSplit converts a comma-separated string or an array to a string slice
+func ldSplit(val any) []string {
+	var result []string
+	for _, s := range ldStrings(val) {
+		for _, part := range strings.Split(s, ",") {
+			result = appendUnique(result, strings.TrimSpace(part))
+		}
+	}
+	return result
+}
+
+// ldList wraps a single value as a one-element slice
+func ldList(val any) []any {
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case []any:
+		return v
+	default:
+		return []any{v}
+	}
+}
+
+// ldInt converts a number or numeric string (e.g. "10.000.000") to int
+func ldInt(val any) int {
+	switch v := val.(type) {
+	case float64:
+		return int(v)
+	case string:
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			if r == '.' || r == ',' || r == ' ' {
+				return -1
+			}
+			return 'x'
+		}, strings.TrimSpace(v))
+		if n, err := strconv.Atoi(digits); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// ldIdentifier extracts the value from a PropertyValue identifier
+func ldIdentifier(val any) string {
+	if m, ok := val.(map[string]any); ok {
+		return ldString(m["value"])
+	}
+	return ldString(val)
+}
+
+// ldExperience converts experienceRequirements to text
+// OccupationalExperienceRequirements objects carry monthsOfExperience
+func ldExperience(val any) string {
+	m, ok := val.(map[string]any)
+	if !ok {
+		return ldString(val)
+	}
+	months := ldInt(m["monthsOfExperience"])
+	switch {
+	case months <= 0:
+		return ldString(m["description"])
+	case months < 12:
+		return "Dưới 1 năm"
+	default:
+		return fmt.Sprintf("%d năm", months/12)
+	}
+}
+
+func appendUnique(list []string, s string) []string {
+	if s == "" {
+		return list
+	}
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,340 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// SiteDefinition describes an HTML-scraped site declaratively (YAML or JSON)
+// so selectors can be added or fixed without a rebuild
+type SiteDefinition struct {
+	// Source identifier stored in RawJob.Source
+	Source string `yaml:"source" json:"source"`
+	// Listing page selectors and pagination
+	List ListDefinition `yaml:"list" json:"list"`
+	// Detail page fields, each mapped to a RawData key
+	Detail []FieldRule `yaml:"detail" json:"detail"`
+	// Optional Next.js script selector (e.g. script#__NEXT_DATA__)
+	NextDataScript string `yaml:"next_data_script" json:"next_data_script"`
+	// Read detail pages from schema.org JobPosting JSON-LD; Detail becomes a fallback
+	JSONLD bool `yaml:"json_ld" json:"json_ld"`
+}
+
+// ListDefinition holds listing page selectors and the pagination URL pattern
+type ListDefinition struct {
+	// FirstPageURL is used for page 1 when set (some sites have no ?page=1)
+	FirstPageURL string `yaml:"first_page_url" json:"first_page_url"`
+	// PageURLPattern builds page N, with {page} as the placeholder
+	// e.g. https://example.vn/viec-lam?page={page}
+	PageURLPattern string `yaml:"page_url_pattern" json:"page_url_pattern"`
+	// Item selects each job card
+	Item string `yaml:"item" json:"item"`
+	// Link selects the job link inside the card (empty = the card itself)
+	Link string `yaml:"link" json:"link"`
+	// LinkAttr is the attribute holding the URL (default href)
+	LinkAttr string `yaml:"link_attr" json:"link_attr"`
+}
+
+// FieldRule extracts one RawData value from a page
+type FieldRule struct {
+	// Key is the RawData key the value is stored under
+	Key string `yaml:"key" json:"key"`
+	// Selector is the CSS selector, relative to <body>
+	Selector string `yaml:"selector" json:"selector"`
+	// Attr extracts an attribute instead of text
+	Attr string `yaml:"attr" json:"attr"`
+	// HTML extracts inner HTML instead of text (ignored when Attr is set)
+	HTML bool `yaml:"html" json:"html"`
+	// Regex post-processes the value; the first capture group is kept if present
+	Regex string `yaml:"regex" json:"regex"`
+	// Multiple collects every match as []string instead of the first one
+	Multiple bool `yaml:"multiple" json:"multiple"`
+	// Required fields are reported by Validate when empty
+	Required bool `yaml:"required" json:"required"`
+
+	re *regexp.Regexp
+}
+
+// LoadSiteDefinition reads a site definition from a .yaml, .yml or .json file
+func LoadSiteDefinition(path string) (*SiteDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read definition: %w", err)
+	}
+
+	var def SiteDefinition
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &def)
+	case ".json":
+		err = json.Unmarshal(data, &def)
+	default:
+		return nil, fmt.Errorf("unsupported definition format: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	if err := def.compile(); err != nil {
+		return nil, fmt.Errorf("invalid definition %s: %w", path, err)
+	}
+	return &def, nil
+}
+
+// LoadSiteDefinitions reads every .yaml, .yml and .json definition in a directory
+func LoadSiteDefinitions(dir string) ([]*SiteDefinition, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read dir: %w", err)
+	}
+
+	var defs []*SiteDefinition
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		def, err := LoadSiteDefinition(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+// NewCollyExtractorFromDefinition builds a ready CollyExtractor for a site definition
+func NewCollyExtractorFromDefinition(def *SiteDefinition, config ExtractorConfig) *CollyExtractor {
+	return NewCollyExtractor(domain.JobSource(def.Source), def.Selectors(), config)
+}
+
+// LoadExtractors loads every definition in dir and returns extractors keyed by source
+func LoadExtractors(dir string, config ExtractorConfig) (map[domain.JobSource]*CollyExtractor, error) {
+	defs, err := LoadSiteDefinitions(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	extractors := make(map[domain.JobSource]*CollyExtractor, len(defs))
+	for _, def := range defs {
+		source := domain.JobSource(def.Source)
+		if _, dup := extractors[source]; dup {
+			return nil, fmt.Errorf("duplicate site definition for %s", source)
+		}
+		extractors[source] = NewCollyExtractorFromDefinition(def, config)
+	}
+	return extractors, nil
+}
+
+// Selectors converts the definition into CollyExtractor selectors
+func (d *SiteDefinition) Selectors() Selectors {
+	return Selectors{
+		JobItem:        d.List.Item,
+		JobLink:        d.List.Link,
+		JobLinkAttr:    d.List.LinkAttr,
+		FirstPageURL:   d.List.FirstPageURL,
+		PageURLPattern: d.List.PageURLPattern,
+		Fields:         d.Detail,
+		NextDataScript: d.NextDataScript,
+		JSONLD:         d.JSONLD,
+	}
+}
+
+// compile validates the definition and compiles its regexes
+func (d *SiteDefinition) compile() error {
+	if d.Source == "" {
+		return fmt.Errorf("source is required")
+	}
+	if d.List.Item == "" {
+		return fmt.Errorf("list.item is required")
+	}
+	if d.List.PageURLPattern != "" && !strings.Contains(d.List.PageURLPattern, "{page}") {
+		return fmt.Errorf("list.page_url_pattern must contain {page}")
+	}
+	if len(d.Detail) == 0 && d.NextDataScript == "" && !d.JSONLD {
+		return fmt.Errorf("detail fields, next_data_script or json_ld are required")
+	}
+
+	seen := make(map[string]bool, len(d.Detail))
+	for i := range d.Detail {
+		f := &d.Detail[i]
+		if f.Key == "" || f.Selector == "" {
+			return fmt.Errorf("detail[%d]: key and selector are required", i)
+		}
+		if seen[f.Key] {
+			return fmt.Errorf("detail[%d]: duplicate key %q", i, f.Key)
+		}
+		seen[f.Key] = true
+
+		if f.Regex != "" {
+			re, err := regexp.Compile(f.Regex)
+			if err != nil {
+				return fmt.Errorf("detail[%d] %s: bad regex: %w", i, f.Key, err)
+			}
+			f.re = re
+		}
+	}
+	return nil
+}
+
+// pageURL builds the listing URL for a page from the selectors
+// Falls back to listURL?page=N when no pattern is configured
+func (s Selectors) pageURL(listURL string, page int) string {
+	if page <= 1 && s.FirstPageURL != "" {
+		return s.FirstPageURL
+	}
+	if s.PageURLPattern != "" {
+		return strings.ReplaceAll(s.PageURLPattern, "{page}", strconv.Itoa(page))
+	}
+	return fmt.Sprintf("%s?page=%d", listURL, page)
+}
+
+// ApplyFields extracts every field rule from a document root into RawData
+func ApplyFields(root *goquery.Selection, fields []FieldRule) map[string]any {
+	rawData := make(map[string]any, len(fields))
+	for _, f := range fields {
+		matches := root.Find(f.Selector)
+
+		if f.Multiple {
+			var values []string
+			matches.Each(func(_ int, sel *goquery.Selection) {
+				if v := f.value(sel); v != "" {
+					values = append(values, v)
+				}
+			})
+			rawData[f.Key] = values
+			continue
+		}
+
+		rawData[f.Key] = f.value(matches.First())
+	}
+	return rawData
+}
+
+// ApplyList extracts absolute job links from a listing document
+func ApplyList(root *goquery.Selection, s Selectors, pageURL string) []string {
+	base, _ := url.Parse(pageURL)
+	attr := s.JobLinkAttr
+	if attr == "" {
+		attr = "href"
+	}
+
+	var links []string
+	root.Find(s.JobItem).Each(func(_ int, item *goquery.Selection) {
+		target := item
+		if s.JobLink != "" {
+			target = item.Find(s.JobLink).First()
+		}
+		link, _ := target.Attr(attr)
+		link = strings.TrimSpace(link)
+		if link == "" {
+			return
+		}
+		if base != nil {
+			if ref, err := url.Parse(link); err == nil {
+				link = base.ResolveReference(ref).String()
+			}
+		}
+		links = append(links, link)
+	})
+	return links
+}
+
+// value extracts and post-processes a single selection
+func (f FieldRule) value(sel *goquery.Selection) string {
+	if sel.Length() == 0 {
+		return ""
+	}
+
+	var v string
+	switch {
+	case f.Attr != "":
+		v, _ = sel.Attr(f.Attr)
+	case f.HTML:
+		v, _ = sel.Html()
+	default:
+		v = sel.Text()
+	}
+	v = strings.TrimSpace(v)
+
+	if f.re != nil && v != "" {
+		m := f.re.FindStringSubmatch(v)
+		switch {
+		case m == nil:
+			v = ""
+		case len(m) > 1:
+			v = strings.TrimSpace(m[1])
+		default:
+			v = strings.TrimSpace(m[0])
+		}
+	}
+	return v
+}
+
+// ValidationResult reports how a definition performed against an HTML fixture
+type ValidationResult struct {
+	RawData map[string]any
+	Links   []string
+	Missing []string // Required fields that came back empty
+}
+
+// Validate runs the definition against a saved HTML page
+// When list is true the fixture is treated as a listing page, otherwise a detail page
+func (d *SiteDefinition) Validate(html, pageURL string, list bool) (*ValidationResult, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+
+	result := &ValidationResult{}
+	if list {
+		result.Links = ApplyList(doc.Selection, d.Selectors(), pageURL)
+		if len(result.Links) == 0 {
+			result.Missing = append(result.Missing, "list.item/link")
+		}
+		return result, nil
+	}
+
+	if d.JSONLD {
+		if postings := ExtractJobPostings(doc.Selection); len(postings) > 0 {
+			result.RawData = JobPostingRawData(postings[0])
+			return result, nil
+		}
+		if len(d.Detail) == 0 {
+			result.Missing = append(result.Missing, "json_ld")
+			return result, nil
+		}
+	}
+
+	result.RawData = ApplyFields(doc.Find("body"), d.Detail)
+	for _, f := range d.Detail {
+		if !f.Required {
+			continue
+		}
+		switch v := result.RawData[f.Key].(type) {
+		case string:
+			if v == "" {
+				result.Missing = append(result.Missing, f.Key)
+			}
+		case []string:
+			if len(v) == 0 {
+				result.Missing = append(result.Missing, f.Key)
+			}
+		}
+	}
+	return result, nil
+}
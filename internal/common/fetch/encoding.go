@@ -0,0 +1,67 @@
+package fetch
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Decompress asks for gzip/brotli responses and decodes them, so callers
+// always read plain bodies while the wire carries compressed ones
+func Decompress(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &decompressTransport{base: base}
+}
+
+type decompressTransport struct {
+	base http.RoundTripper
+}
+
+func (t *decompressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", "gzip, br")
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip":
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("gzip response: %w", err)
+		}
+		body = zr
+	case "br":
+		body = brotli.NewReader(resp.Body)
+	default:
+		return resp, nil
+	}
+
+	resp.Body = &decodedBody{Reader: body, raw: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+type decodedBody struct {
+	io.Reader
+	raw io.ReadCloser
+}
+
+func (b *decodedBody) Close() error {
+	return b.raw.Close()
+}
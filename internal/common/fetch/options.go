@@ -0,0 +1,72 @@
+package fetch
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/project-tktt/go-crawler/internal/common/breaker"
+	"github.com/project-tktt/go-crawler/internal/common/cassette"
+	"github.com/project-tktt/go-crawler/internal/common/proxy"
+	"github.com/project-tktt/go-crawler/internal/common/ratelimit"
+	"github.com/project-tktt/go-crawler/internal/common/robots"
+)
+
+// Options bundles the shared HTTP layers every fetcher goes through
+// The zero value is a plain http.DefaultTransport
+type Options struct {
+	// Limiter is the shared per-host rate limiter (optional)
+	Limiter *ratelimit.Limiter
+	// Retry is the retry budget for transient failures
+	Retry Policy
+	// Proxies rotates requests across a proxy pool (optional)
+	Proxies *proxy.Pool
+	// Robots enforces robots.txt disallow rules and Crawl-delay (optional)
+	Robots *robots.Policy
+	// Cassette records traffic or replays it offline (optional)
+	Cassette *cassette.Cassette
+	// Breaker pauses the source after ban signals (optional)
+	Breaker *breaker.Breaker
+	// Decompress negotiates gzip/brotli and decodes bodies above the network
+	// layers, so the breaker can inspect them
+	Decompress bool
+}
+
+// Transport wraps base (nil = http.DefaultTransport) as breaker → decompress →
+// robots → retry → rate limit → cassette → proxy → base: a paused source and
+// disallowed URLs are rejected before any attempt, and every attempt waits
+// for a token, is recorded and may go out through a different proxy
+// A replaying cassette answers everything itself, without the network layers
+func (o Options) Transport(base http.RoundTripper) http.RoundTripper {
+	if o.Cassette.Replaying() {
+		return o.decompress(o.Cassette.Transport(nil))
+	}
+	if o.Proxies != nil {
+		switch t := base.(type) {
+		case nil:
+			base = o.Proxies.Transport(nil)
+		case *http.Transport:
+			base = o.Proxies.Transport(t)
+		default:
+			log.Printf("[Fetch] Proxy pool skipped: base transport %T is not *http.Transport", base)
+		}
+	}
+	if o.Cassette != nil {
+		base = o.Cassette.Transport(base)
+	}
+	return o.Breaker.Transport(o.decompress(o.Robots.Transport(o.Retry.Transport(o.Limiter.Transport(base)))))
+}
+
+func (o Options) decompress(rt http.RoundTripper) http.RoundTripper {
+	if !o.Decompress {
+		return rt
+	}
+	return Decompress(rt)
+}
+
+// WithTokenAuth marks requests made with ctx as carrying a bearer token the
+// caller refreshes on 401/403, so neither the breaker nor the proxy pool
+// takes a rejected token for a ban
+func WithTokenAuth(ctx context.Context) context.Context {
+	return proxy.WithTokenAuth(breaker.WithTokenAuth(ctx))
+}
@@ -0,0 +1,41 @@
+package fetch
+
+import (
+	"fmt"
+	"log"
+)
+
+// PageErrors decides whether a crawl loop skips a failed page or gives up
+// Retryable failures (already retried by the transport) are skipped until
+// MaxConsecutive pages in a row fail; fatal failures end the run at once
+type PageErrors struct {
+	MaxConsecutive int
+	consecutive    int
+}
+
+// NewPageErrors creates a page error tracker (default 3 consecutive failures)
+func NewPageErrors(maxConsecutive int) *PageErrors {
+	if maxConsecutive <= 0 {
+		maxConsecutive = 3
+	}
+	return &PageErrors{MaxConsecutive: maxConsecutive}
+}
+
+// Fail records a failed page; it returns nil to skip the page and continue,
+// or an error to end the run
+func (p *PageErrors) Fail(tag string, page int, err error) error {
+	p.consecutive++
+	if IsFatal(err) {
+		return fmt.Errorf("page %d: %w", page, err)
+	}
+	if p.consecutive >= p.MaxConsecutive {
+		return fmt.Errorf("%d consecutive page failures, last on page %d: %w", p.consecutive, page, err)
+	}
+	log.Printf("%s Skipping page %d after retries: %v", tag, page, err)
+	return nil
+}
+
+// OK resets the consecutive failure count after a good page
+func (p *PageErrors) OK() {
+	p.consecutive = 0
+}
@@ -0,0 +1,218 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Policy is a retry budget with exponential backoff and full jitter
+type Policy struct {
+	// MaxRetries is the number of retries after the first attempt (0 = no retry)
+	MaxRetries int
+	// BaseDelay is the backoff for the first retry (default 1s), doubled each time
+	BaseDelay time.Duration
+	// MaxDelay caps backoff and Retry-After waits (default 60s)
+	MaxDelay time.Duration
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = time.Second
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = 60 * time.Second
+	}
+	return p
+}
+
+// Backoff returns the wait before retry n (0-based): a random duration in
+// [d/2, d] where d = BaseDelay * 2^n, capped at MaxDelay
+func (p Policy) Backoff(n int) time.Duration {
+	p = p.withDefaults()
+	d := p.BaseDelay << min(n, 16)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// Transport wraps base with retries for retryable failures
+// Each attempt goes through base, so a rate-limiting base is charged per attempt
+// A nil base uses http.DefaultTransport; a zero MaxRetries returns base unchanged
+func (p Policy) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if p.MaxRetries <= 0 {
+		return base
+	}
+	return &retryTransport{base: base, policy: p.withDefaults()}
+}
+
+type retryTransport struct {
+	base   http.RoundTripper
+	policy Policy
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Buffer the body once so every attempt can resend it
+	if req.Body != nil && req.GetBody == nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("buffer request body: %w", err)
+		}
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.Body, _ = req.GetBody()
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+
+		retryable := err != nil && IsRetryable(err) ||
+			err == nil && RetryableStatus(resp.StatusCode)
+		if !retryable || attempt >= t.policy.MaxRetries {
+			return resp, err
+		}
+
+		wait := t.policy.Backoff(attempt)
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			if ra := RetryAfter(resp); ra > 0 {
+				wait = min(ra, t.policy.MaxDelay)
+			}
+			// Drain so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		log.Printf("[Fetch] %s %s: %s, retry %d/%d in %v", req.Method, req.URL.Host, reason, attempt+1, t.policy.MaxRetries, wait.Round(time.Millisecond))
+
+		if err := Sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("rewind request body: %w", err)
+			}
+			req.Body = body
+		}
+	}
+}
+
+// StatusError is a non-2xx response
+type StatusError struct {
+	StatusCode int
+	URL        string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status: %d", e.StatusCode)
+}
+
+// CheckResponse returns a *StatusError for non-2xx responses
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		URL:        resp.Request.URL.String(),
+		RetryAfter: RetryAfter(resp),
+	}
+}
+
+// RetryableStatus reports whether a status is worth retrying
+// (timeouts, throttling and transient server errors)
+func RetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// IsRetryable classifies an error: transient network failures and retryable
+// statuses are retryable; cancellation, bad requests and other 4xx are fatal
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return RetryableStatus(statusErr.StatusCode)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return !dnsErr.IsNotFound
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
+
+// IsFatal reports errors that will not go away on retry (e.g. 403, 404, bad URL)
+func IsFatal(err error) bool {
+	return err != nil && !IsRetryable(err)
+}
+
+// RetryAfter parses the Retry-After header (seconds or HTTP date)
+// on 429/503 responses; 0 when absent
+func RetryAfter(resp *http.Response) time.Duration {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// Sleep waits for d, returning early with ctx's error once it is cancelled
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,173 @@
+package httpcache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Entry is a cached response with its validators
+type Entry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	// Body is the gzip-compressed response body (empty when over the size limit)
+	Body []byte `json:"body,omitempty"`
+	// Result is data derived from the body (e.g. enrichment) reused on 304
+	Result   map[string]any `json:"result,omitempty"`
+	StoredAt time.Time      `json:"stored_at"`
+}
+
+// NewEntry builds an entry from a response header and data derived from its body
+func NewEntry(url string, header http.Header, result map[string]any) *Entry {
+	return &Entry{
+		URL:          url,
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		Result:       result,
+	}
+}
+
+// Conditional adds If-None-Match / If-Modified-Since to req
+func (e *Entry) Conditional(req *http.Request) {
+	if e == nil {
+		return
+	}
+	if e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+}
+
+// Content returns the decompressed body (nil when it was not stored)
+func (e *Entry) Content() ([]byte, error) {
+	if e == nil || len(e.Body) == 0 {
+		return nil, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(e.Body))
+	if err != nil {
+		return nil, fmt.Errorf("decompress cached body: %w", err)
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// Config holds cache options
+type Config struct {
+	// Prefix for Redis keys (default "httpcache")
+	Prefix string
+	// TTL is how long an entry lives without being revalidated (default 7 days)
+	TTL time.Duration
+	// MaxBodySize caps the compressed body per entry; larger bodies keep only
+	// validators and Result (default 512KB)
+	MaxBodySize int
+}
+
+// Cache stores validators, compressed bodies and derived results in Redis
+type Cache struct {
+	redis  *redis.Client
+	config Config
+}
+
+// NewCache creates a Redis-backed response cache
+func NewCache(client *redis.Client, cfg Config) *Cache {
+	if cfg.Prefix == "" {
+		cfg.Prefix = "httpcache"
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 7 * 24 * time.Hour
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = 512 << 10
+	}
+	return &Cache{redis: client, config: cfg}
+}
+
+func (c *Cache) key(url string) string {
+	return c.config.Prefix + ":" + url
+}
+
+// Get returns the entry for url, or nil when there is none
+func (c *Cache) Get(ctx context.Context, url string) (*Entry, error) {
+	if c == nil {
+		return nil, nil
+	}
+	data, err := c.redis.Get(ctx, c.key(url)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get cache entry: %w", err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("decode cache entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// Put stores an entry with its plain response body, compressed
+// Responses without ETag or Last-Modified cannot be revalidated and are skipped
+func (c *Cache) Put(ctx context.Context, entry *Entry, body []byte) error {
+	if c == nil || entry == nil || (entry.ETag == "" && entry.LastModified == "") {
+		return nil
+	}
+
+	stored := *entry
+	stored.StoredAt = time.Now()
+	stored.Body = nil
+	if len(body) > 0 {
+		compressed, err := compress(body)
+		if err != nil {
+			return err
+		}
+		if len(compressed) <= c.config.MaxBodySize {
+			stored.Body = compressed
+		}
+	}
+	return c.save(ctx, &stored)
+}
+
+// Touch extends the TTL of an entry confirmed by a 304
+func (c *Cache) Touch(ctx context.Context, entry *Entry) error {
+	if c == nil || entry == nil {
+		return nil
+	}
+	touched := *entry
+	touched.StoredAt = time.Now()
+	return c.save(ctx, &touched)
+}
+
+func (c *Cache) save(ctx context.Context, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode cache entry: %w", err)
+	}
+	if err := c.redis.Set(ctx, c.key(entry.URL), data, c.config.TTL).Err(); err != nil {
+		return fmt.Errorf("set cache entry: %w", err)
+	}
+	return nil
+}
+
+func compress(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, fmt.Errorf("compress body: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compress body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -80,8 +80,17 @@ func (i *ElasticsearchIndexer) BulkIndex(ctx context.Context, jobs []*domain.Job
 	}
 
 	var buf bytes.Buffer
+	var failed BulkError
 
 	for _, job := range jobs {
+		// Document line first, so a job that fails to marshal leaves no meta line
+		docBytes, err := json.Marshal(job)
+		if err != nil {
+			log.Printf("marshal job %s: %v", job.ID, err)
+			failed.add(job.ID, fmt.Sprintf("marshal: %v", err))
+			continue
+		}
+
 		// Meta line
 		meta := map[string]any{
 			"index": map[string]any{
@@ -93,16 +102,14 @@ func (i *ElasticsearchIndexer) BulkIndex(ctx context.Context, jobs []*domain.Job
 		buf.Write(metaBytes)
 		buf.WriteByte('\n')
 
-		// Document line
-		docBytes, err := json.Marshal(job)
-		if err != nil {
-			log.Printf("marshal job %s: %v", job.ID, err)
-			continue
-		}
 		buf.Write(docBytes)
 		buf.WriteByte('\n')
 	}
 
+	if buf.Len() == 0 {
+		return failed.errOrNil()
+	}
+
 	res, err := i.client.Bulk(bytes.NewReader(buf.Bytes()), i.client.Bulk.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("bulk request: %w", err)
@@ -137,11 +144,13 @@ func (i *ElasticsearchIndexer) BulkIndex(ctx context.Context, jobs []*domain.Job
 			if item.Index.Status >= 400 {
 				log.Printf("bulk index error for %s: %s - %s",
 					item.Index.ID, item.Index.Error.Type, item.Index.Error.Reason)
+				failed.add(item.Index.ID, fmt.Sprintf("status %d: %s - %s",
+					item.Index.Status, item.Index.Error.Type, item.Index.Error.Reason))
 			}
 		}
 	}
 
-	return nil
+	return failed.errOrNil()
 }
 
 // EnsureIndex creates the index with Vietnamese-friendly settings if it doesn't exist
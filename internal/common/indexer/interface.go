@@ -2,6 +2,7 @@ package indexer
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/project-tktt/go-crawler/internal/domain"
 )
@@ -9,5 +10,32 @@ import (
 // Indexer defines the interface for job indexing backends
 type Indexer interface {
 	// BulkIndex indexes multiple jobs at once
+	// A *BulkError means the other jobs were indexed
 	BulkIndex(ctx context.Context, jobs []*domain.Job) error
 }
+
+// BulkError reports the jobs of a bulk request that could not be indexed
+type BulkError struct {
+	// Failed maps job IDs to the reason they were rejected
+	Failed map[string]string
+}
+
+func (e *BulkError) Error() string {
+	return fmt.Sprintf("%d jobs failed to index", len(e.Failed))
+}
+
+// add records a failed job
+func (e *BulkError) add(id, reason string) {
+	if e.Failed == nil {
+		e.Failed = make(map[string]string)
+	}
+	e.Failed[id] = reason
+}
+
+// errOrNil returns e when jobs failed
+func (e *BulkError) errOrNil() error {
+	if len(e.Failed) == 0 {
+		return nil
+	}
+	return e
+}
@@ -244,6 +244,7 @@ func (i *PostgresIndexer) BulkIndex(ctx context.Context, jobs []*domain.Job) err
 	}
 	defer stmt.Close()
 
+	var failed BulkError
 	for _, job := range jobs {
 		expTags := "{}"
 		if len(job.ExpTags) > 0 {
@@ -270,6 +271,11 @@ func (i *PostgresIndexer) BulkIndex(ctx context.Context, jobs []*domain.Job) err
 			locationDistrictArr = "{" + strings.Join(job.LocationDistrict, ",") + "}"
 		}
 
+		// A failed statement aborts the transaction; the savepoint keeps the
+		// rest of the batch
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT job"); err != nil {
+			return fmt.Errorf("savepoint: %w", err)
+		}
 		_, err := stmt.ExecContext(ctx,
 			job.ID, job.Title, job.Company, job.Location, job.Position,
 			job.Salary, job.SalaryMin, job.SalaryMax, job.WorkType, industryArr, job.Field,
@@ -280,6 +286,10 @@ func (i *PostgresIndexer) BulkIndex(ctx context.Context, jobs []*domain.Job) err
 		)
 		if err != nil {
 			log.Printf("Error indexing job %s: %v", job.ID, err)
+			failed.add(job.ID, err.Error())
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT job"); err != nil {
+				return fmt.Errorf("rollback to savepoint: %w", err)
+			}
 			continue
 		}
 	}
@@ -288,7 +298,7 @@ func (i *PostgresIndexer) BulkIndex(ctx context.Context, jobs []*domain.Job) err
 		return fmt.Errorf("commit transaction: %w", err)
 	}
 
-	return nil
+	return failed.errOrNil()
 }
 
 // Close closes the database connection
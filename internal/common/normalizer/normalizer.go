@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/project-tktt/go-crawler/internal/common/dateutil"
 	"github.com/project-tktt/go-crawler/internal/domain"
 )
 
@@ -30,14 +31,18 @@ func (n *Normalizer) Normalize(raw *domain.RawJob) (*domain.Job, error) {
 		CrawledAt: raw.ExtractedAt,
 	}
 
-	// Normalize based on source
-	switch raw.Source {
-	case string(domain.SourceVietnamWorks):
+	// Normalize based on layout marker, then source
+	switch {
+	case getString(data, domain.RawDataLayoutKey) == domain.LayoutJobPosting:
+		n.normalizeJobPosting(job, data)
+	case raw.Source == string(domain.SourceVietnamWorks):
 		n.normalizeVietnamWorks(job, data)
-	case string(domain.SourceTopDev):
+	case raw.Source == string(domain.SourceTopDev):
 		n.normalizeTopDev(job, data)
-	case string(domain.SourceVieclam24h):
+	case raw.Source == string(domain.SourceVieclam24h):
 		n.normalizeVieclam24h(job, data)
+	case raw.Source == string(domain.SourceTopCV):
+		n.normalizeTopCV(job, data)
 	default:
 		n.normalizeGeneric(job, data)
 	}
@@ -298,14 +303,164 @@ func (n *Normalizer) normalizeTopDev(job *domain.Job, data map[string]any) {
 	skills := parseSkillsArray(data["skills"])
 	if len(skills) > 0 {
 		job.Field = strings.Join(skills, ", ")
+		job.Skills = skills
 	}
 
 	// Parse experience
 	job.Experience = parseExperience(data["experience"])
 	job.ExpTags = mapExperienceToTags(job.Experience)
 
-	// Parse level
+	// Parse level and job type (string, {"name": ...} or array of them)
 	job.Position = parseLevel(data["level"])
+	job.WorkType = parseLevel(data["job_type"])
+	job.EmploymentType = job.WorkType
+
+	// Dates from detail fetch
+	if expiredAt := getString(data, "expired_at"); expiredAt != "" {
+		job.ExpiredAt = dateutil.Parse(expiredAt)
+	}
+	if publishedAt := getString(data, "published_at"); publishedAt != "" {
+		job.CreatedAt = dateutil.Parse(publishedAt)
+	}
+}
+
+// normalizeTopCV handles TopCV-specific data (parsed from __NEXT_DATA__)
+func (n *Normalizer) normalizeTopCV(job *domain.Job, data map[string]any) {
+	job.Title = getString(data, "title")
+	job.Company = getString(data, "company")
+	job.CompanyWebsite = getString(data, "company_website")
+	job.Description = getString(data, "description")
+	job.Requirements = getString(data, "requirement")
+	job.Benefits = getString(data, "benefits")
+
+	// Locations are pre-split by the crawler
+	locations := parseLocationsArray(data["locations"])
+	if len(locations) > 0 {
+		job.Location = strings.Join(locations, "; ")
+	}
+	job.LocationCity = getStringArray(data, "location_city")
+	job.LocationDistrict = getStringArray(data, "location_district")
+
+	// Parse salary from integers
+	job.SalaryMin = getInt(data, "salary_min")
+	job.SalaryMax = getInt(data, "salary_max")
+
+	// TopCV returns salary in VND, convert to millions
+	if job.SalaryMin > 1000 {
+		job.SalaryMin = job.SalaryMin / 1000000
+	}
+	if job.SalaryMax > 1000 {
+		job.SalaryMax = job.SalaryMax / 1000000
+	}
+
+	salaryText := getString(data, "salary_text")
+	if getBool(data, "salary_negotiable") {
+		job.IsNegotiable = true
+		job.Salary = salaryText
+		if job.Salary == "" {
+			job.Salary = "Thỏa thuận"
+		}
+		job.SalaryMin = 0
+		job.SalaryMax = 0
+	} else if salaryText != "" {
+		job.Salary = salaryText
+		job.IsNegotiable = isNegotiableSalary(salaryText)
+		if job.SalaryMin == 0 && job.SalaryMax == 0 {
+			job.SalaryMin, job.SalaryMax = parseSalary(salaryText)
+		}
+	} else if job.SalaryMin > 0 && job.SalaryMax > 0 {
+		job.Salary = fmt.Sprintf("%d - %d triệu", job.SalaryMin, job.SalaryMax)
+	} else if job.SalaryMin > 0 {
+		job.Salary = fmt.Sprintf("Trên %d triệu", job.SalaryMin)
+	} else {
+		job.Salary = "Thỏa thuận"
+		job.IsNegotiable = true
+	}
+
+	// Classification
+	job.Experience = getString(data, "experience")
+	job.ExpTags = mapExperienceToTags(job.Experience)
+	job.Position = getString(data, "level")
+	job.WorkType = getString(data, "working_form")
+	job.EmploymentType = job.WorkType
+	job.Industry = getStringArray(data, "industry")
+	job.Skills = parseSkillsArray(data["skills"])
+	if len(job.Skills) > 0 {
+		job.Field = strings.Join(job.Skills, ", ")
+	}
+
+	// Stats
+	job.TotalViews = getInt(data, "total_views")
+	job.TotalResumeApplied = getInt(data, "total_applied")
+
+	// Dates
+	if deadline := getString(data, "deadline"); deadline != "" {
+		job.ExpiredAt = dateutil.Parse(deadline)
+	}
+	if createdAt := getString(data, "created_at"); createdAt != "" {
+		job.CreatedAt = dateutil.Parse(createdAt)
+	}
+	if updatedAt := getString(data, "updated_at"); updatedAt != "" {
+		job.UpdatedAt = dateutil.Parse(updatedAt)
+	}
+}
+
+// normalizeJobPosting handles the standard layout built by extractor.JobPostingRawData
+// Any source whose detail pages carry schema.org JobPosting JSON-LD lands here
+func (n *Normalizer) normalizeJobPosting(job *domain.Job, data map[string]any) {
+	job.Title = getString(data, "title")
+	job.Company = getString(data, "companyName")
+	job.CompanyWebsite = getString(data, "companyWebsite")
+	job.Description = getString(data, "description")
+	job.Requirements = getString(data, "responsibilities", "qualifications")
+	job.Qualifications = getString(data, "qualifications")
+	job.Benefits = getString(data, "jobBenefits")
+	job.Skills = parseSkillsString(data["skills"])
+	job.Experience = getString(data, "experienceRequirements")
+
+	// Classification
+	job.Position = getString(data, "occupationalCategory")
+	job.OccupationalCategory = job.Position
+	job.EmploymentType = getString(data, "employmentType")
+	job.WorkType = job.EmploymentType
+	job.Industry = getStringArray(data, "industry")
+
+	// Locations (multi-location jobs keep every city/district)
+	job.LocationCity = getStringArray(data, "locationCity")
+	job.LocationDistrict = getStringArray(data, "locationDistrict")
+	job.Location = strings.Join(getStringArray(data, "streetAddress"), "; ")
+
+	// Salary: numeric range first, then free text
+	job.SalaryMin = getInt(data, "salaryMin")
+	job.SalaryMax = getInt(data, "salaryMax")
+	switch {
+	case job.SalaryMin > 0 && job.SalaryMax > job.SalaryMin:
+		job.Salary = fmt.Sprintf("%d - %d triệu", job.SalaryMin/1000000, job.SalaryMax/1000000)
+	case job.SalaryMin > 0 && job.SalaryMax == job.SalaryMin:
+		job.Salary = fmt.Sprintf("%d triệu", job.SalaryMin/1000000)
+	case job.SalaryMin > 0:
+		job.Salary = fmt.Sprintf("Trên %d triệu", job.SalaryMin/1000000)
+	case job.SalaryMax > 0:
+		job.Salary = fmt.Sprintf("Tới %d triệu", job.SalaryMax/1000000)
+	default:
+		job.Salary = getString(data, "salaryText")
+		if job.Salary == "" {
+			job.Salary = "Thỏa thuận"
+		}
+		job.IsNegotiable = isNegotiableSalary(job.Salary)
+	}
+
+	// Convert to millions for storage
+	if job.SalaryMin > 1000 {
+		job.SalaryMin = job.SalaryMin / 1000000
+	}
+	if job.SalaryMax > 1000 {
+		job.SalaryMax = job.SalaryMax / 1000000
+	}
+
+	// Dates
+	job.CreatedAt = dateutil.Parse(getString(data, "datePosted"))
+	job.ExpiredAt = dateutil.Parse(getString(data, "validThrough"))
 }
 
 // normalizeGeneric handles generic data format
@@ -417,14 +572,14 @@ func parseUnixTimestamp(val any) time.Time {
 	}
 	switch v := val.(type) {
 	case float64:
-		return time.Unix(int64(v), 0)
+		return dateutil.Unix(int64(v))
 	case int64:
-		return time.Unix(v, 0)
+		return dateutil.Unix(v)
 	case int:
-		return time.Unix(int64(v), 0)
+		return dateutil.Unix(int64(v))
 	case string:
 		if ts, err := strconv.ParseInt(v, 10, 64); err == nil {
-			return time.Unix(ts, 0)
+			return dateutil.Unix(ts)
 		}
 	case time.Time:
 		return v
@@ -752,6 +907,17 @@ func parseExperience(val any) string {
 		return fmt.Sprintf("%.0f năm", v)
 	case int:
 		return fmt.Sprintf("%d năm", v)
+	case map[string]any:
+		// Range format: {"min": 1, "max": 3}
+		min, max := getInt(v, "min"), getInt(v, "max")
+		switch {
+		case min > 0 && max > 0:
+			return fmt.Sprintf("%d - %d năm", min, max)
+		case min > 0:
+			return fmt.Sprintf("Trên %d năm", min)
+		case max > 0:
+			return fmt.Sprintf("Dưới %d năm", max)
+		}
 	}
 	return ""
 }
@@ -768,6 +934,14 @@ func parseLevel(val any) string {
 		if name, ok := v["name"].(string); ok {
 			return name
 		}
+	case []any:
+		var names []string
+		for _, item := range v {
+			if name := parseLevel(item); name != "" {
+				names = append(names, name)
+			}
+		}
+		return strings.Join(names, ", ")
 	}
 	return ""
 }
@@ -803,21 +977,12 @@ func parseSalary(salary string) (min, max int) {
 	return 0, 0
 }
 
-// NormalizeTime parses various time formats
+// NormalizeTime parses various time formats (zone-less ones in Vietnam time),
+// falling back to the current time
 func NormalizeTime(s string) time.Time {
-	formats := []string{
-		"2006-01-02",
-		"02/01/2006",
-		"2006-01-02T15:04:05Z",
-		time.RFC3339,
-	}
-
-	for _, format := range formats {
-		if t, err := time.Parse(format, s); err == nil {
-			return t
-		}
+	if t := dateutil.Parse(s); !t.IsZero() {
+		return t
 	}
-
 	return time.Now()
 }
 
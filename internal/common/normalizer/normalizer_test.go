@@ -0,0 +1,141 @@
+package normalizer
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/common/cleaner"
+	"github.com/project-tktt/go-crawler/internal/domain"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files from the current output")
+
+func TestMain(m *testing.M) {
+	// Dates without a zone are read in Vietnam time; a host in another zone
+	// must not change the output
+	time.Local = time.FixedZone("EST", -5*60*60)
+	os.Exit(m.Run())
+}
+
+// TestGolden normalizes every testdata/*.input.json RawJob the way the worker
+// does (CleanMap, Normalize, CleanToText) and compares it with *.golden.json
+// Run with -update to accept the current output
+func TestGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.input.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no fixtures in testdata")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".input.json")
+		golden := filepath.Join("testdata", name+".golden.json")
+
+		t.Run(name, func(t *testing.T) {
+			got := normalizeFixture(t, input)
+
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("read golden file (run with -update to create it): %v", err)
+			}
+			if diff := diffFields(want, got); diff != "" {
+				t.Errorf("%s mismatch (-want +got):\n%s", golden, diff)
+			}
+		})
+	}
+}
+
+// normalizeFixture runs the worker's clean + normalize steps on a RawJob file
+func normalizeFixture(t *testing.T, path string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw domain.RawJob
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("decode %s: %v", path, err)
+	}
+
+	c := cleaner.NewCleaner()
+	if raw.RawData != nil {
+		raw.RawData = c.CleanMap(raw.RawData)
+	}
+	job, err := NewNormalizer().Normalize(&raw)
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	job.Description = c.CleanToText(job.Description)
+	job.Requirements = c.CleanToText(job.Requirements)
+	job.Benefits = c.CleanToText(job.Benefits)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(job); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// diffFields compares two Job JSON documents field by field
+func diffFields(want, got []byte) string {
+	var wantFields, gotFields map[string]any
+	if err := json.Unmarshal(want, &wantFields); err != nil {
+		return fmt.Sprintf("invalid golden file: %v", err)
+	}
+	if err := json.Unmarshal(got, &gotFields); err != nil {
+		return fmt.Sprintf("invalid output: %v", err)
+	}
+
+	keys := make(map[string]bool)
+	for k := range wantFields {
+		keys[k] = true
+	}
+	for k := range gotFields {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var b strings.Builder
+	for _, k := range sorted {
+		w, g := wantFields[k], gotFields[k]
+		if reflect.DeepEqual(w, g) {
+			continue
+		}
+		fmt.Fprintf(&b, "  %s:\n    - %s\n    + %s\n", k, compact(w), compact(g))
+	}
+	return b.String()
+}
+
+func compact(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,326 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rotation selects how often the pool switches proxies
+type Rotation string
+
+const (
+	// RotatePerRequest picks the next healthy proxy for every request
+	RotatePerRequest Rotation = "request"
+	// RotatePerSession keeps one proxy per session key until it fails
+	RotatePerSession Rotation = "session"
+)
+
+// Config describes where proxies come from and how they are rotated
+type Config struct {
+	// URLs is an inline proxy list (e.g. from PROXY_URL / PROXY_LIST)
+	URLs []string
+	// File holds one proxy URL per line; blank lines and # comments are skipped
+	File string
+	// Rotation defaults to RotatePerRequest
+	Rotation Rotation
+	// Cooldown is how long an unhealthy proxy rests (default 5m)
+	Cooldown time.Duration
+	// MaxFailures is the number of consecutive errors before a cooldown (default 3)
+	MaxFailures int
+}
+
+// Status is a snapshot of one proxy's health
+type Status struct {
+	URL       string
+	Healthy   bool
+	DownUntil time.Time
+	Requests  int
+	Failures  int
+	Bans      int
+}
+
+type entry struct {
+	url       *url.URL
+	failures  int // Consecutive failures
+	downUntil time.Time
+	requests  int
+	totalFail int
+	bans      int
+}
+
+// Pool rotates requests across proxies and rests the ones that fail or get banned
+type Pool struct {
+	mu          sync.Mutex
+	entries     []*entry
+	next        int
+	sessions    map[string]*entry
+	rotation    Rotation
+	cooldown    time.Duration
+	maxFailures int
+}
+
+// Load builds a pool from the config; it returns nil (direct connections)
+// when no proxies are configured
+func Load(cfg Config) (*Pool, error) {
+	raw := append([]string(nil), cfg.URLs...)
+	if cfg.File != "" {
+		lines, err := readLines(cfg.File)
+		if err != nil {
+			return nil, fmt.Errorf("read proxy file: %w", err)
+		}
+		raw = append(raw, lines...)
+	}
+
+	if cfg.Rotation == "" {
+		cfg.Rotation = RotatePerRequest
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 5 * time.Minute
+	}
+	if cfg.MaxFailures <= 0 {
+		cfg.MaxFailures = 3
+	}
+
+	p := &Pool{
+		sessions:    make(map[string]*entry),
+		rotation:    cfg.Rotation,
+		cooldown:    cfg.Cooldown,
+		maxFailures: cfg.MaxFailures,
+	}
+	seen := make(map[string]bool)
+	for _, r := range raw {
+		r = strings.TrimSpace(r)
+		if r == "" || seen[r] {
+			continue
+		}
+		seen[r] = true
+		u, err := url.Parse(r)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %q", r)
+		}
+		p.entries = append(p.entries, &entry{url: u})
+	}
+
+	if len(p.entries) == 0 {
+		return nil, nil
+	}
+	log.Printf("[Proxy] Loaded %d proxies (rotation: %s)", len(p.entries), p.rotation)
+	return p, nil
+}
+
+// Next returns the proxy to use for a session key (ignored for per-request rotation)
+// When every proxy is resting, the one that recovers first is returned
+func (p *Pool) Next(session string) *url.URL {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if p.rotation == RotatePerSession && session != "" {
+		if e, ok := p.sessions[session]; ok && e.downUntil.Before(now) {
+			e.requests++
+			return e.url
+		}
+	}
+
+	var chosen *entry
+	for i := 0; i < len(p.entries); i++ {
+		e := p.entries[(p.next+i)%len(p.entries)]
+		if e.downUntil.Before(now) {
+			chosen = e
+			p.next = (p.next + i + 1) % len(p.entries)
+			break
+		}
+	}
+	if chosen == nil {
+		chosen = p.entries[0]
+		for _, e := range p.entries[1:] {
+			if e.downUntil.Before(chosen.downUntil) {
+				chosen = e
+			}
+		}
+	}
+
+	if p.rotation == RotatePerSession && session != "" {
+		p.sessions[session] = chosen
+	}
+	chosen.requests++
+	return chosen.url
+}
+
+// Report records the outcome of a request through a proxy
+// Ban signals rest the proxy at once; other failures after MaxFailures in a row
+func (p *Pool) Report(proxyURL *url.URL, resp *http.Response, err error) {
+	if proxyURL == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e := p.find(proxyURL)
+	if e == nil {
+		return
+	}
+
+	switch {
+	case err == nil && !IsBanStatus(resp.StatusCode):
+		e.failures = 0
+	case err == nil:
+		e.bans++
+		e.totalFail++
+		p.rest(e, fmt.Sprintf("ban signal %d", resp.StatusCode))
+	default:
+		e.failures++
+		e.totalFail++
+		if e.failures >= p.maxFailures {
+			p.rest(e, fmt.Sprintf("%d consecutive errors: %v", e.failures, err))
+		}
+	}
+}
+
+// rest puts a proxy on cooldown and drops its sticky sessions
+func (p *Pool) rest(e *entry, reason string) {
+	e.failures = 0
+	e.downUntil = time.Now().Add(p.cooldown)
+	for key, s := range p.sessions {
+		if s == e {
+			delete(p.sessions, key)
+		}
+	}
+	log.Printf("[Proxy] %s unhealthy (%s), cooling down %v", e.url.Redacted(), reason, p.cooldown)
+}
+
+func (p *Pool) find(u *url.URL) *entry {
+	for _, e := range p.entries {
+		if e.url == u || e.url.String() == u.String() {
+			return e
+		}
+	}
+	return nil
+}
+
+// Stats returns the health of every proxy
+func (p *Pool) Stats() []Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]Status, 0, len(p.entries))
+	for _, e := range p.entries {
+		stats = append(stats, Status{
+			URL:       e.url.Redacted(),
+			Healthy:   e.downUntil.Before(now),
+			DownUntil: e.downUntil,
+			Requests:  e.requests,
+			Failures:  e.totalFail,
+			Bans:      e.bans,
+		})
+	}
+	return stats
+}
+
+// IsBanStatus reports responses that usually mean the proxy IP is blocked
+func IsBanStatus(code int) bool {
+	return code == http.StatusForbidden || code == http.StatusProxyAuthRequired || code == http.StatusTooManyRequests
+}
+
+type ctxKey int
+
+const (
+	proxyKey ctxKey = iota
+	sessionKey
+	tokenAuthKey
+)
+
+// WithSession pins requests made with ctx to one proxy under RotatePerSession
+func WithSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, sessionKey, session)
+}
+
+// WithTokenAuth marks requests made with ctx as carrying a token the caller
+// refreshes on 401/403; a 403 then means a rejected token and does not rest
+// the proxy
+func WithTokenAuth(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tokenAuthKey, true)
+}
+
+// ProxyFunc is the proxy switcher for http.Transport.Proxy and colly.SetProxyFunc
+// It uses the proxy chosen by Transport for this request, or picks one itself
+// (sessions default to the request host)
+func (p *Pool) ProxyFunc() func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if u, ok := req.Context().Value(proxyKey).(*url.URL); ok {
+			return u, nil
+		}
+		return p.Next(sessionFor(req)), nil
+	}
+}
+
+// Transport routes requests through the pool and reports each outcome
+// base is cloned (nil = http.DefaultTransport) and its Proxy replaced
+func (p *Pool) Transport(base *http.Transport) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	t := base.Clone()
+	t.Proxy = p.ProxyFunc()
+	return &transport{base: t, pool: p}
+}
+
+type transport struct {
+	base http.RoundTripper
+	pool *Pool
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	proxyURL := t.pool.Next(sessionFor(req))
+	req = req.WithContext(context.WithValue(req.Context(), proxyKey, proxyURL))
+
+	resp, err := t.base.RoundTrip(req)
+	if req.Context().Err() == nil && !rejectedToken(req, resp, err) {
+		t.pool.Report(proxyURL, resp, err)
+	}
+	return resp, err
+}
+
+// rejectedToken reports a 401/403 on a request marked with WithTokenAuth
+func rejectedToken(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		return false
+	}
+	auth, _ := req.Context().Value(tokenAuthKey).(bool)
+	return auth && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden)
+}
+
+func sessionFor(req *http.Request) string {
+	if s, ok := req.Context().Value(sessionKey).(string); ok {
+		return s
+	}
+	return req.URL.Host
+}
+
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
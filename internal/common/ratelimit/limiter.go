@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Limit is a token bucket: Rate tokens per second, up to Burst tokens
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Every returns a limit of one request per interval with no burst
+func Every(interval time.Duration) Limit {
+	if interval <= 0 {
+		return Limit{}
+	}
+	return Limit{Rate: float64(time.Second) / float64(interval), Burst: 1}
+}
+
+// tokenBucket atomically refills and takes one token
+// Returns 0 when a token was taken, otherwise the milliseconds until one is available
+var tokenBucket = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + (now - ts) * rate / 1000)
+
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tokens, "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst * 1000 / rate) + 60000)
+return wait
+`)
+
+// Limiter is a Redis-backed token bucket per host, shared by every process
+// (crawler replicas, enrichers) that points at the same Redis
+type Limiter struct {
+	client       *redis.Client
+	prefix       string
+	defaultLimit Limit
+
+	mu     sync.RWMutex
+	limits map[string]Limit
+}
+
+// NewLimiter creates a distributed limiter; defaultLimit applies to hosts without SetLimit
+// A zero Rate disables limiting for those hosts
+func NewLimiter(client *redis.Client, defaultLimit Limit) *Limiter {
+	return &Limiter{
+		client:       client,
+		prefix:       "ratelimit",
+		defaultLimit: defaultLimit,
+		limits:       make(map[string]Limit),
+	}
+}
+
+// SetLimit configures the bucket for a host (e.g. apiv2.vieclam24h.vn)
+func (l *Limiter) SetLimit(host string, limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits[normalizeHost(host)] = limit
+}
+
+// Wait blocks until the host of rawURL has a token or ctx is done
+// A nil Limiter never blocks
+func (l *Limiter) Wait(ctx context.Context, rawURL string) error {
+	if l == nil {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("parse url: %w", err)
+	}
+	return l.WaitHost(ctx, u.Host)
+}
+
+// WaitHost blocks until host has a token or ctx is done
+func (l *Limiter) WaitHost(ctx context.Context, host string) error {
+	if l == nil {
+		return nil
+	}
+	host = normalizeHost(host)
+	limit := l.limitFor(host)
+	if limit.Rate <= 0 {
+		return nil
+	}
+	burst := max(limit.Burst, 1)
+	key := fmt.Sprintf("%s:%s", l.prefix, host)
+
+	for {
+		wait, err := tokenBucket.Run(ctx, l.client, []string{key}, limit.Rate, burst, time.Now().UnixMilli()).Int64()
+		if err != nil {
+			// Fail open: a Redis outage must not stop crawling; fall back to a local pause
+			log.Printf("[RateLimit] %s: %v", host, err)
+			wait = int64(1000 / limit.Rate)
+			return sleep(ctx, time.Duration(wait)*time.Millisecond)
+		}
+		if wait <= 0 {
+			return nil
+		}
+		if err := sleep(ctx, time.Duration(wait)*time.Millisecond); err != nil {
+			return err
+		}
+	}
+}
+
+func (l *Limiter) limitFor(host string) Limit {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if limit, ok := l.limits[host]; ok {
+		return limit
+	}
+	return l.defaultLimit
+}
+
+// Transport wraps base so every request waits for its host's token
+// A nil base uses http.DefaultTransport
+func (l *Limiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if l == nil {
+		return base
+	}
+	return &transport{base: base, limiter: l}
+}
+
+type transport struct {
+	base    http.RoundTripper
+	limiter *Limiter
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.WaitHost(req.Context(), req.URL.Host); err != nil {
+		return nil, fmt.Errorf("rate limit: %w", err)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// normalizeHost lowercases and drops the port and a leading www.
+func normalizeHost(host string) string {
+	host = strings.ToLower(host)
+	if h, _, ok := strings.Cut(host, ":"); ok {
+		host = h
+	}
+	return strings.TrimPrefix(host, "www.")
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,248 @@
+package robots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/temoto/robotstxt"
+)
+
+// Mode selects how strictly a source follows robots.txt
+type Mode string
+
+const (
+	// ModeObey skips disallowed URLs and waits for Crawl-delay (default)
+	ModeObey Mode = "obey"
+	// ModeDelay only honors Crawl-delay
+	ModeDelay Mode = "delay"
+	// ModeIgnore turns the policy off
+	ModeIgnore Mode = "ignore"
+)
+
+// ErrDisallowed is matched by errors.Is for URLs blocked by robots.txt
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// DisallowedError reports a URL skipped because of robots.txt
+type DisallowedError struct {
+	URL   string
+	Agent string
+}
+
+func (e *DisallowedError) Error() string {
+	return fmt.Sprintf("%s disallowed by robots.txt for %q", e.URL, e.Agent)
+}
+
+func (e *DisallowedError) Is(target error) bool {
+	return target == ErrDisallowed
+}
+
+// Config holds cache options
+type Config struct {
+	// Agent is matched against robots.txt groups when a request has no User-Agent
+	Agent string
+	// TTL is how long a robots.txt is cached per host (default 24h)
+	TTL time.Duration
+	// MaxCrawlDelay caps Crawl-delay values (default 30s)
+	MaxCrawlDelay time.Duration
+}
+
+// Cache fetches and caches robots.txt per host; it is shared by every source
+type Cache struct {
+	config Config
+	mu     sync.Mutex
+	hosts  map[string]*host
+}
+
+type host struct {
+	mu      sync.Mutex // Serializes robots.txt fetches
+	data    *robotstxt.RobotsData
+	expires time.Time
+	next    time.Time // Earliest start of the next request (Crawl-delay)
+}
+
+// NewCache creates a robots.txt cache
+func NewCache(cfg Config) *Cache {
+	if cfg.Agent == "" {
+		cfg.Agent = "*"
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	if cfg.MaxCrawlDelay <= 0 {
+		cfg.MaxCrawlDelay = 30 * time.Second
+	}
+	return &Cache{config: cfg, hosts: make(map[string]*host)}
+}
+
+// Policy returns the policy for a source; nil (no checks) for ModeIgnore
+// Unknown modes fall back to ModeObey
+func (c *Cache) Policy(source string, mode Mode) *Policy {
+	if c == nil || mode == ModeIgnore {
+		return nil
+	}
+	if mode != ModeDelay {
+		mode = ModeObey
+	}
+	return &Policy{cache: c, source: source, mode: mode}
+}
+
+func (c *Cache) host(key string) *host {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h, ok := c.hosts[key]
+	if !ok {
+		h = &host{}
+		c.hosts[key] = h
+	}
+	return h
+}
+
+// rules returns the cached robots.txt for u's host, fetching it through rt when stale
+func (c *Cache) rules(ctx context.Context, rt http.RoundTripper, u *url.URL) *robotstxt.RobotsData {
+	h := c.host(u.Scheme + "://" + u.Host)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.data != nil && time.Now().Before(h.expires) {
+		return h.data
+	}
+
+	data, err := fetchRobots(ctx, rt, u)
+	if err != nil {
+		// Fail open, and try again sooner than a normal refresh
+		log.Printf("[Robots] %s: %v, allowing all for now", u.Host, err)
+		data, _ = robotstxt.FromStatusAndBytes(http.StatusNotFound, nil)
+		h.data, h.expires = data, time.Now().Add(10*time.Minute)
+		return data
+	}
+	h.data, h.expires = data, time.Now().Add(c.config.TTL)
+	return data
+}
+
+func fetchRobots(ctx context.Context, rt http.RoundTripper, u *url.URL) (*robotstxt.RobotsData, error) {
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 512<<10))
+	if err != nil {
+		return nil, fmt.Errorf("read robots.txt: %w", err)
+	}
+	// 4xx allows everything, 5xx disallows everything (per the robots spec)
+	data, err := robotstxt.FromStatusAndBytes(resp.StatusCode, body)
+	if err != nil {
+		return nil, fmt.Errorf("parse robots.txt: %w", err)
+	}
+	return data, nil
+}
+
+// wait reserves the next request slot on u's host and sleeps until it starts
+func (c *Cache) wait(ctx context.Context, u *url.URL, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	if delay > c.config.MaxCrawlDelay {
+		delay = c.config.MaxCrawlDelay
+	}
+
+	h := c.host(u.Scheme + "://" + u.Host)
+	h.mu.Lock()
+	now := time.Now()
+	start := h.next
+	if start.Before(now) {
+		start = now
+	}
+	h.next = start.Add(delay)
+	h.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Policy enforces robots.txt for one source
+type Policy struct {
+	cache  *Cache
+	source string
+	mode   Mode
+}
+
+// Check fetches (or reuses) robots.txt for u and returns the group that
+// applies to agent; rt is used for the robots.txt request
+func (p *Policy) Check(ctx context.Context, rt http.RoundTripper, u *url.URL, agent string) (*robotstxt.Group, error) {
+	if agent == "" {
+		agent = p.cache.config.Agent
+	}
+	group := p.cache.rules(ctx, rt, u).FindGroup(agent)
+
+	if p.mode == ModeObey && !group.Test(pathOf(u)) {
+		return group, &DisallowedError{URL: u.String(), Agent: agent}
+	}
+	return group, nil
+}
+
+// Transport checks every request against robots.txt before passing it to
+// base; robots.txt itself is fetched through base
+func (p *Policy) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if p == nil {
+		return base
+	}
+	return &transport{base: base, policy: p}
+}
+
+type transport struct {
+	base   http.RoundTripper
+	policy *Policy
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Path == "/robots.txt" {
+		return t.base.RoundTrip(req)
+	}
+
+	group, err := t.policy.Check(req.Context(), t.base, req.URL, req.Header.Get("User-Agent"))
+	if err != nil {
+		log.Printf("[Robots] %s: skipped %v", t.policy.source, err)
+		return nil, err
+	}
+	if err := t.policy.cache.wait(req.Context(), req.URL, group.CrawlDelay); err != nil {
+		return nil, fmt.Errorf("crawl delay: %w", err)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// pathOf returns the path and query matched against robots.txt rules
+func pathOf(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
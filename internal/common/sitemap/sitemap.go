@@ -0,0 +1,277 @@
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/common/fetch"
+)
+
+// maxBodySize caps a single (decompressed) sitemap at 50MB, the protocol limit
+const maxBodySize = 50 << 20
+
+// Entry is a single <url> from a sitemap
+type Entry struct {
+	Loc     string
+	LastMod time.Time // Zero when the sitemap has no <lastmod>
+}
+
+// Config holds discovery options
+type Config struct {
+	UserAgent string
+	// Sitemaps skips robots.txt and starts from these URLs
+	Sitemaps []string
+	// SitemapFilter only follows child sitemaps of an index whose URL matches
+	// (e.g. job sitemaps only); nil follows every child
+	SitemapFilter *regexp.Regexp
+	// MaxDepth limits nested sitemap indexes (default 3)
+	MaxDepth int
+	// HTTP holds the shared rate limit, retry and proxy layers for
+	// robots.txt and sitemap requests
+	HTTP fetch.Options
+}
+
+// Fetcher discovers URLs from robots.txt, sitemap indexes and (gzip) sitemaps
+type Fetcher struct {
+	client *http.Client
+	config Config
+}
+
+// NewFetcher creates a new sitemap fetcher
+func NewFetcher(cfg Config) *Fetcher {
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "Mozilla/5.0 (compatible; JobCrawler/1.0)"
+	}
+	if cfg.MaxDepth <= 0 {
+		cfg.MaxDepth = 3
+	}
+	return &Fetcher{
+		client: &http.Client{Timeout: 60 * time.Second, Transport: cfg.HTTP.Transport(nil)},
+		config: cfg,
+	}
+}
+
+// urlSet is a <urlset> sitemap
+type urlSet struct {
+	URLs []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"url"`
+}
+
+// sitemapIndex is a <sitemapindex> listing child sitemaps
+type sitemapIndex struct {
+	Sitemaps []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"sitemap"`
+}
+
+// Discover returns every URL reachable from the site's sitemaps
+// Sitemaps come from Config.Sitemaps, else robots.txt, else /sitemap.xml
+func (f *Fetcher) Discover(ctx context.Context, siteURL string) ([]Entry, error) {
+	roots := f.config.Sitemaps
+	if len(roots) == 0 {
+		var err error
+		roots, err = f.RobotsSitemaps(ctx, siteURL)
+		if err != nil {
+			log.Printf("[Sitemap] robots.txt unavailable for %s: %v", siteURL, err)
+		}
+	}
+	if len(roots) == 0 {
+		roots = []string{strings.TrimRight(siteURL, "/") + "/sitemap.xml"}
+	}
+
+	seen := make(map[string]bool)
+	var entries []Entry
+	for _, root := range roots {
+		found, err := f.fetchSitemap(ctx, root, 0, seen)
+		if err != nil {
+			log.Printf("[Sitemap] Error reading %s: %v", root, err)
+			continue
+		}
+		entries = append(entries, found...)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no sitemap entries found for %s", siteURL)
+	}
+	return entries, nil
+}
+
+// RobotsSitemaps returns the Sitemap: lines of the site's robots.txt
+func (f *Fetcher) RobotsSitemaps(ctx context.Context, siteURL string) ([]string, error) {
+	u, err := url.Parse(siteURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse site url: %w", err)
+	}
+	robotsURL := u.Scheme + "://" + u.Host + "/robots.txt"
+
+	body, err := f.get(ctx, robotsURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var sitemaps []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "sitemap") {
+			continue
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			sitemaps = append(sitemaps, value)
+		}
+	}
+	return sitemaps, scanner.Err()
+}
+
+// fetchSitemap reads a sitemap or sitemap index, recursing into children
+func (f *Fetcher) fetchSitemap(ctx context.Context, sitemapURL string, depth int, seen map[string]bool) ([]Entry, error) {
+	if seen[sitemapURL] {
+		return nil, nil
+	}
+	seen[sitemapURL] = true
+
+	body, err := f.get(ctx, sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, children, err := Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", sitemapURL, err)
+	}
+
+	if len(children) > 0 {
+		if depth >= f.config.MaxDepth {
+			return entries, fmt.Errorf("sitemap index too deep at %s", sitemapURL)
+		}
+		for _, child := range children {
+			if f.config.SitemapFilter != nil && !f.config.SitemapFilter.MatchString(child) {
+				continue
+			}
+			found, err := f.fetchSitemap(ctx, child, depth+1, seen)
+			if err != nil {
+				log.Printf("[Sitemap] Error reading %s: %v", child, err)
+				continue
+			}
+			entries = append(entries, found...)
+		}
+	}
+
+	log.Printf("[Sitemap] %s: %d urls, %d child sitemaps", sitemapURL, len(entries), len(children))
+	return entries, nil
+}
+
+// Parse decodes a sitemap body (plain or gzip) into URL entries and child sitemap URLs
+func Parse(body []byte) (entries []Entry, children []string, err error) {
+	body, err = gunzip(body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Sniff the root element to pick the right shape
+	if bytes.Contains(body[:min(len(body), 1024)], []byte("<sitemapindex")) {
+		var index sitemapIndex
+		if err := xml.Unmarshal(body, &index); err != nil {
+			return nil, nil, fmt.Errorf("decode sitemap index: %w", err)
+		}
+		for _, s := range index.Sitemaps {
+			if loc := strings.TrimSpace(s.Loc); loc != "" {
+				children = append(children, loc)
+			}
+		}
+		return nil, children, nil
+	}
+
+	var set urlSet
+	if err := xml.Unmarshal(body, &set); err != nil {
+		return nil, nil, fmt.Errorf("decode urlset: %w", err)
+	}
+	for _, u := range set.URLs {
+		loc := strings.TrimSpace(u.Loc)
+		if loc == "" {
+			continue
+		}
+		entries = append(entries, Entry{Loc: loc, LastMod: ParseLastMod(u.LastMod)})
+	}
+	return entries, nil, nil
+}
+
+// ParseLastMod parses W3C datetime values used by <lastmod>
+// Returns zero time when empty or malformed
+func ParseLastMod(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+	layouts := []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04Z07:00",
+		"2006-01-02 15:04:05",
+		"2006-01-02",
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// get fetches a URL and returns its body, capped at maxBodySize
+func (f *Fetcher) get(ctx context.Context, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("User-Agent", f.config.UserAgent)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := fetch.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	return body, nil
+}
+
+// gunzip decompresses gzip bodies (detected by magic bytes, not the .gz suffix,
+// since servers may or may not set Content-Encoding)
+func gunzip(body []byte) ([]byte, error) {
+	if len(body) < 2 || body[0] != 0x1f || body[1] != 0x8b {
+		return body, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("gzip reader: %w", err)
+	}
+	defer zr.Close()
+
+	out, err := io.ReadAll(io.LimitReader(zr, maxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("gunzip: %w", err)
+	}
+	return out, nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +14,7 @@ type Config struct {
 	Postgres      PostgresConfig
 	Crawler       CrawlerConfig
 	Worker        WorkerConfig
+	Retry         RetryConfig
 }
 
 type PostgresConfig struct {
@@ -28,6 +30,12 @@ type RedisConfig struct {
 	DB       int
 	// Queue names
 	JobQueue string
+	// JobQueue backend: "list" (LPUSH/LMOVE) or "stream" (XADD/XREADGROUP)
+	JobQueueBackend string
+	// Approximate stream length kept by XADD MAXLEN (stream backend)
+	JobQueueMaxLen int64
+	// Dead letters kept per stage, oldest dropped first
+	DeadLetterMaxLen int
 }
 
 type ESConfig struct {
@@ -39,10 +47,57 @@ type CrawlerConfig struct {
 	// Rate limiting
 	RequestDelay time.Duration
 	MaxRetries   int
-	// Proxy settings (for VietnamWorks)
-	ProxyURL string
+	// Proxy pool: PROXY_URL and PROXY_LIST (comma-separated) plus PROXY_FILE
+	// (one URL per line); empty = direct connections
+	ProxyURL  string
+	ProxyList []string
+	ProxyFile string
+	// "request" rotates every request, "session" keeps one proxy per host until it fails
+	ProxyRotation string
+	// How long a failing or banned proxy rests before it is tried again
+	ProxyCooldown time.Duration
 	// User agent
 	UserAgent string
+	// Sources to run in cmd/crawler (empty = all registered sources)
+	Sources []string
+	// Log every job with status and URL
+	VerboseLog bool
+	// Incremental mode: stop after N fully unchanged pages (0 = always full crawl)
+	StopAfterUnchangedPages int
+	// Force a full sweep when the last one is older than this
+	FullSweepInterval time.Duration
+	// Interrupted runs older than this start again from page 1
+	CheckpointTTL time.Duration
+	// Default per-host rate limit shared by all replicas (Redis token bucket)
+	RateLimit RateLimit
+	// Per-source overrides from CRAWLER_RATE_LIMIT_<SOURCE>=rps[:burst]
+	SourceRateLimits map[string]RateLimit
+	// Per-source retry budgets from CRAWLER_MAX_RETRIES_<SOURCE> (default MaxRetries)
+	SourceMaxRetries map[string]int
+	// robots.txt policy: "obey" (disallow + Crawl-delay), "delay" (Crawl-delay only) or "ignore"
+	Robots string
+	// Per-source overrides from CRAWLER_ROBOTS_<SOURCE>
+	SourceRobots map[string]string
+	// HTTP mode: "off", "record" (write cassettes) or "replay" (serve cassettes offline)
+	HTTPMode string
+	// Directory holding one <source>.jsonl cassette per source
+	CassetteDir string
+	// API token secrets files from CRAWLER_TOKEN_FILE_<SOURCE>
+	TokenFiles map[string]string
+	// Detail page cache: entry lifetime and compressed body size limit
+	DetailCacheTTL   time.Duration
+	DetailCacheMaxKB int
+	// Circuit breaker: consecutive ban signals before a source pauses (0 = off),
+	// first pause (doubled per trip) and the longest pause
+	BreakerThreshold   int
+	BreakerCooldown    time.Duration
+	BreakerMaxCooldown time.Duration
+}
+
+// RateLimit is a requests-per-second budget with burst
+type RateLimit struct {
+	RPS   float64
+	Burst int
 }
 
 type WorkerConfig struct {
@@ -50,6 +105,33 @@ type WorkerConfig struct {
 	Concurrency int
 	// Batch size for Elasticsearch bulk indexing
 	BatchSize int
+	// Consumer group of this worker on a stream job queue; each group
+	// (e.g. Elasticsearch and Postgres workers) reads every job
+	Group string
+	// Processing list / group consumer name of this worker (default hostname)
+	ConsumerName string
+	// Jobs in flight longer than this are returned to the queue
+	VisibilityTimeout time.Duration
+}
+
+// RetryConfig holds delayed retries of failed jobs (enricher fetches, worker indexing)
+type RetryConfig struct {
+	// Deliveries before a failed job is dead-lettered (1 = no retries)
+	MaxAttempts int
+	// Per-stage overrides from RETRY_MAX_ATTEMPTS_<STAGE> ("-" written as "_")
+	StageMaxAttempts map[string]int
+	// First retry delay, doubled per attempt up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// MaxAttemptsFor returns a stage's attempt budget (RETRY_MAX_ATTEMPTS_<STAGE>,
+// else RETRY_MAX_ATTEMPTS)
+func (c RetryConfig) MaxAttemptsFor(stage string) int {
+	if n, ok := c.StageMaxAttempts[strings.ReplaceAll(strings.ToLower(stage), "-", "_")]; ok {
+		return n
+	}
+	return c.MaxAttempts
 }
 
 // Load creates a Config from environment variables with defaults
@@ -60,6 +142,11 @@ func Load() *Config {
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       getEnvInt("REDIS_DB", 0),
 			JobQueue: getEnv("REDIS_JOB_QUEUE", "jobs:raw"),
+
+			JobQueueBackend: getEnv("REDIS_JOB_QUEUE_BACKEND", "list"),
+			JobQueueMaxLen:  int64(getEnvInt("REDIS_JOB_QUEUE_MAXLEN", 100000)),
+
+			DeadLetterMaxLen: getEnvInt("REDIS_DLQ_MAXLEN", 10000),
 		},
 		Elasticsearch: ESConfig{
 			Addresses: []string{getEnv("ELASTICSEARCH_URL", "http://localhost:9200")},
@@ -73,11 +160,44 @@ func Load() *Config {
 			RequestDelay: time.Duration(getEnvInt("CRAWLER_DELAY_MS", 1000)) * time.Millisecond,
 			MaxRetries:   getEnvInt("CRAWLER_MAX_RETRIES", 3),
 			ProxyURL:     getEnv("PROXY_URL", ""),
+			ProxyList:    getEnvList("PROXY_LIST"),
+			ProxyFile:    getEnv("PROXY_FILE", ""),
 			UserAgent:    getEnv("USER_AGENT", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"),
+			Sources:      getEnvList("CRAWLER_SOURCES"),
+			VerboseLog:   getEnvBool("CRAWLER_VERBOSE_LOG", false),
+
+			StopAfterUnchangedPages: getEnvInt("CRAWLER_STOP_AFTER_UNCHANGED_PAGES", 3),
+			FullSweepInterval:       time.Duration(getEnvInt("CRAWLER_FULL_SWEEP_HOURS", 24)) * time.Hour,
+			CheckpointTTL:           time.Duration(getEnvInt("CRAWLER_CHECKPOINT_TTL_HOURS", 12)) * time.Hour,
+			RateLimit:               parseRateLimit(getEnv("CRAWLER_RATE_LIMIT", "1:2")),
+			SourceRateLimits:        getSourceRateLimits("CRAWLER_RATE_LIMIT_"),
+			SourceMaxRetries:        getSourceInts("CRAWLER_MAX_RETRIES_"),
+			Robots:                  getEnv("CRAWLER_ROBOTS", "obey"),
+			SourceRobots:            getSourceEnv("CRAWLER_ROBOTS_"),
+			HTTPMode:                getEnv("CRAWLER_HTTP_MODE", "off"),
+			CassetteDir:             getEnv("CRAWLER_CASSETTE_DIR", "testdata/cassettes"),
+			TokenFiles:              getSourceEnv("CRAWLER_TOKEN_FILE_"),
+			DetailCacheTTL:          time.Duration(getEnvInt("CRAWLER_DETAIL_CACHE_HOURS", 168)) * time.Hour,
+			DetailCacheMaxKB:        getEnvInt("CRAWLER_DETAIL_CACHE_MAX_KB", 512),
+			BreakerThreshold:        getEnvInt("CRAWLER_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:         time.Duration(getEnvInt("CRAWLER_BREAKER_COOLDOWN_SECONDS", 300)) * time.Second,
+			BreakerMaxCooldown:      time.Duration(getEnvInt("CRAWLER_BREAKER_MAX_COOLDOWN_HOURS", 6)) * time.Hour,
+			ProxyRotation:           getEnv("PROXY_ROTATION", "request"),
+			ProxyCooldown:           time.Duration(getEnvInt("PROXY_COOLDOWN_SECONDS", 300)) * time.Second,
 		},
 		Worker: WorkerConfig{
 			Concurrency: getEnvInt("WORKER_CONCURRENCY", 5),
 			BatchSize:   getEnvInt("WORKER_BATCH_SIZE", 100),
+
+			Group:             getEnv("WORKER_GROUP", "workers"),
+			ConsumerName:      getEnv("WORKER_CONSUMER_NAME", ""),
+			VisibilityTimeout: time.Duration(getEnvInt("WORKER_VISIBILITY_TIMEOUT_SECONDS", 300)) * time.Second,
+		},
+		Retry: RetryConfig{
+			MaxAttempts:      getEnvInt("RETRY_MAX_ATTEMPTS", 5),
+			StageMaxAttempts: getSourceInts("RETRY_MAX_ATTEMPTS_"),
+			BaseDelay:        time.Duration(getEnvInt("RETRY_BASE_DELAY_SECONDS", 30)) * time.Second,
+			MaxDelay:         time.Duration(getEnvInt("RETRY_MAX_DELAY_SECONDS", 3600)) * time.Second,
 		},
 	}
 }
@@ -97,3 +217,71 @@ func getEnvInt(key string, defaultVal int) int {
 	}
 	return defaultVal
 }
+
+func getEnvBool(key string, defaultVal bool) bool {
+	if val := os.Getenv(key); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
+	return defaultVal
+}
+
+// parseRateLimit parses "rps[:burst]" (e.g. "0.5" or "2:4"); invalid values disable the limit
+func parseRateLimit(s string) RateLimit {
+	rpsStr, burstStr, _ := strings.Cut(strings.TrimSpace(s), ":")
+	rps, err := strconv.ParseFloat(rpsStr, 64)
+	if err != nil || rps <= 0 {
+		return RateLimit{}
+	}
+	burst := 1
+	if b, err := strconv.Atoi(burstStr); err == nil && b > 0 {
+		burst = b
+	}
+	return RateLimit{RPS: rps, Burst: burst}
+}
+
+// getSourceRateLimits collects PREFIX<SOURCE>=rps[:burst] overrides keyed by lowercase source
+func getSourceRateLimits(prefix string) map[string]RateLimit {
+	limits := make(map[string]RateLimit)
+	for source, val := range getSourceEnv(prefix) {
+		limits[source] = parseRateLimit(val)
+	}
+	return limits
+}
+
+// getSourceInts collects PREFIX<SOURCE>=n overrides keyed by lowercase source
+func getSourceInts(prefix string) map[string]int {
+	values := make(map[string]int)
+	for source, val := range getSourceEnv(prefix) {
+		if i, err := strconv.Atoi(val); err == nil {
+			values[source] = i
+		}
+	}
+	return values
+}
+
+// getSourceEnv returns PREFIX<SOURCE> env vars keyed by lowercase source
+func getSourceEnv(prefix string) map[string]string {
+	values := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, val, _ := strings.Cut(kv, "=")
+		source, ok := strings.CutPrefix(key, prefix)
+		if !ok || source == "" {
+			continue
+		}
+		values[strings.ToLower(source)] = val
+	}
+	return values
+}
+
+// getEnvList parses a comma-separated env var, skipping empty entries
+func getEnvList(key string) []string {
+	var result []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
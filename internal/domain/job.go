@@ -65,3 +65,10 @@ const (
 	SourceTopDev       JobSource = "topdev"
 	SourceVieclam24h   JobSource = "vieclam24h"
 )
+
+// RawDataLayoutKey marks RawData that follows a source-independent layout
+// rather than a source-specific one
+const RawDataLayoutKey = "_layout"
+
+// LayoutJobPosting is RawData produced from a schema.org JobPosting (JSON-LD)
+const LayoutJobPosting = "schema.org/JobPosting"
@@ -2,14 +2,19 @@ package careerviet
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
-	"regexp"
+	"strings"
 	"time"
 
+	"github.com/project-tktt/go-crawler/internal/common/dateutil"
+	"github.com/project-tktt/go-crawler/internal/common/dedup"
 	extractor2 "github.com/project-tktt/go-crawler/internal/common/extractor"
+	"github.com/project-tktt/go-crawler/internal/common/fetch"
 	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/module"
 )
 
 const (
@@ -21,6 +26,7 @@ const (
 type Crawler struct {
 	extractor extractor2.Extractor
 	config    Config
+	dedup     *dedup.Deduplicator // Skips detail pages of unchanged jobs (nil = fetch all)
 }
 
 // Config holds CareerViet-specific configuration
@@ -43,71 +49,212 @@ func NewCrawler(ext extractor2.Extractor, cfg Config) *Crawler {
 	}
 }
 
+// WithDedup fetches detail pages only for jobs that are new or changed: HTML
+// listing entries are compared by their update date, API entries (which have
+// none) by whether they are tracked at all, leaving their changes to the
+// careerviet_sitemap crawler
+func (c *Crawler) WithDedup(d *dedup.Deduplicator) *Crawler {
+	c.dedup = d
+	return c
+}
+
 // NewDefaultExtractor creates the hybrid CareerViet extractor (HTML + API)
 // This extractor fetches all 50 jobs per page: 20 from HTML, 30 from API
 func NewDefaultExtractor(cfg extractor2.ExtractorConfig) extractor2.Extractor {
 	return extractor2.NewCareerVietExtractor(cfg)
 }
 
-// buildPageURL creates the correct pagination URL for CareerViet
-// Format: tat-ca-viec-lam-trang-N-vi.html
-func buildPageURL(page int) string {
-	if page <= 1 {
-		return ListingURL
-	}
-	return regexp.MustCompile(`-vi\.html$`).ReplaceAllString(ListingURL, fmt.Sprintf("-trang-%d-vi.html", page))
-}
-
 // Crawl fetches job listings from CareerViet
 func (c *Crawler) Crawl(ctx context.Context) ([]*domain.RawJob, error) {
 	var allJobs []*domain.RawJob
+	err := c.CrawlWithCallback(ctx, func(jobs []*domain.RawJob) error {
+		allJobs = append(allJobs, jobs...)
+		return nil
+	})
+	return allJobs, err
+}
+
+// CrawlWithCallback fetches jobs page by page and calls handler after each page
+// Pagination is handled by the extractor (tat-ca-viec-lam-trang-N-vi.html)
+func (c *Crawler) CrawlWithCallback(ctx context.Context, handler module.JobHandler) error {
+	totalJobCount := 0
+	pageErrors := fetch.NewPageErrors(0)
 
 	for page := 1; page <= c.config.MaxPages; page++ {
 		select {
 		case <-ctx.Done():
-			return allJobs, ctx.Err()
+			return ctx.Err()
 		default:
 		}
 
 		log.Printf("[CareerViet] Crawling page %d/%d", page, c.config.MaxPages)
 
-		listURL := buildPageURL(page)
-
-		jobs, err := c.extractor.ExtractList(ctx, listURL, page)
+		listJobs, err := c.extractor.ExtractList(ctx, ListingURL, page)
 		if err != nil {
-			log.Printf("[CareerViet] Error on page %d: %v", page, err)
+			// Skip a page that kept failing after retries; give up on fatal errors
+			if err := pageErrors.Fail("[CareerViet]", page, err); err != nil {
+				log.Printf("[CareerViet] Stopping: %v", err)
+				return err
+			}
 			continue
 		}
+		pageErrors.OK()
 
-		if len(jobs) == 0 {
+		if len(listJobs) == 0 {
 			log.Printf("[CareerViet] No more jobs on page %d", page)
 			break
 		}
 
-		for _, job := range jobs {
-			if job.URL == "" {
+		jobs := make([]*domain.RawJob, 0, len(listJobs))
+		unchanged := 0
+		for _, listJob := range listJobs {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if listJob.URL == "" {
+				continue
+			}
+			if c.unchanged(ctx, listJob) {
+				unchanged++
 				continue
 			}
 
-			detail, err := c.extractor.Extract(ctx, job.URL)
+			detail, err := c.extractor.Extract(ctx, listJob.URL)
 			if err != nil {
-				log.Printf("[CareerViet] Error extracting %s: %v", job.URL, err)
-				continue
+				log.Printf("[CareerViet] Error extracting %s: %v", listJob.URL, err)
+			} else {
+				jobs = append(jobs, mergeListing(detail, listJob))
 			}
 
-			allJobs = append(allJobs, detail)
 			// Random delay: base delay + random 0-2000ms
 			randomDelay := c.config.RequestDelay + time.Duration(rand.Intn(2000))*time.Millisecond
-			time.Sleep(randomDelay)
+			if err := fetch.Sleep(ctx, randomDelay); err != nil {
+				return err
+			}
+		}
+		if unchanged > 0 {
+			log.Printf("[CareerViet] Page %d: skipped %d unchanged jobs", page, unchanged)
+		}
+
+		// Process jobs immediately via callback
+		if len(jobs) > 0 {
+			if err := handler(jobs); err != nil {
+				if errors.Is(err, module.ErrStopCrawl) {
+					log.Printf("[CareerViet] Early stop after page %d (unchanged pages)", page)
+					break
+				}
+				return fmt.Errorf("handle page %d: %w", page, err)
+			}
 		}
 
+		totalJobCount += len(jobs)
+		log.Printf("[CareerViet] Page %d: %d/%d jobs processed", page, len(jobs), len(listJobs))
+
 		// Random delay between pages
 		randomDelay := c.config.RequestDelay + time.Duration(rand.Intn(2000))*time.Millisecond
 		time.Sleep(randomDelay)
 	}
 
-	log.Printf("[CareerViet] Crawled %d jobs", len(allJobs))
-	return allJobs, nil
+	log.Printf("[CareerViet] Crawled %d jobs total", totalJobCount)
+	return nil
+}
+
+// unchanged reports whether a listing entry needs no detail fetch: its update
+// date matches the stored marker, or it has no date and is already tracked
+func (c *Crawler) unchanged(ctx context.Context, listJob *domain.RawJob) bool {
+	if c.dedup == nil || listJob.ID == "" {
+		return false
+	}
+	marker, err := c.dedup.Marker(ctx, string(domain.SourceCareerViet), listJob.ID)
+	if err != nil {
+		log.Printf("[CareerViet] Dedup check error for %s: %v", listJob.ID, err)
+		return false
+	}
+	if marker == "" {
+		return false
+	}
+	day := updatedDay(rawText(listJob.RawData, "updated"))
+	return day == "" || strings.HasPrefix(marker, day+"|")
+}
+
+// FetchDetail extracts a single job detail page (used by sitemap discovery)
+func (c *Crawler) FetchDetail(ctx context.Context, jobURL string) (*domain.RawJob, error) {
+	detail, err := c.extractor.Extract(ctx, jobURL)
+	if err != nil {
+		return nil, err
+	}
+	return mergeListing(detail, &domain.RawJob{}), nil
+}
+
+// mergeListing fills gaps in the detail job with data from the listing entry
+// API listing entries carry EXPIRE_DATE, which drives the TTL
+func mergeListing(detail, listing *domain.RawJob) *domain.RawJob {
+	// Marked before the merge, so listing, API and sitemap entries agree
+	detail.LastUpdatedOn = updateMarker(detail)
+	if detail.ID == "" {
+		detail.ID = listing.ID
+	}
+	if detail.RawData == nil {
+		detail.RawData = make(map[string]any)
+	}
+	for k, v := range listing.RawData {
+		if _, exists := detail.RawData[k]; !exists {
+			detail.RawData[k] = v
+		}
+	}
+
+	if !listing.ExpiredOn.IsZero() {
+		detail.ExpiredOn = listing.ExpiredOn
+	}
+	if detail.ExpiredOn.IsZero() {
+		detail.ExpiredOn = time.Now().Add(30 * 24 * time.Hour) // Default 30 days
+	}
+
+	return detail
+}
+
+// updateMarker builds a stable change-detection value for a job from its
+// detail page fields
+// EXPIRE_DATE is the application deadline, not an update time, so the detail
+// page's update date is combined with a fingerprint of the content fields;
+// edits to a job change the fingerprint even when the update date stays the same
+func updateMarker(job *domain.RawJob) string {
+	fingerprint := dedup.Fingerprint(
+		rawText(job.RawData, "title"),
+		rawText(job.RawData, "company"),
+		rawText(job.RawData, "salary"),
+		rawText(job.RawData, "location"),
+		rawText(job.RawData, "experience"),
+		rawText(job.RawData, "benefits"),
+		rawText(job.RawData, "description"),
+		rawText(job.RawData, "expire"),
+	)
+	day := updatedDay(job.LastUpdatedOn)
+	if day == "" {
+		return fingerprint
+	}
+	return day + "|" + fingerprint
+}
+
+// updatedDay normalizes an update date from the listing (dd-mm-yyyy) or the
+// detail page (dd/mm/yyyy), so both compare equal
+func updatedDay(s string) string {
+	if t := dateutil.Parse(s); !t.IsZero() {
+		return t.Format("2006-01-02")
+	}
+	return strings.TrimSpace(s)
+}
+
+// rawText returns a RawData field as text, joining lists
+func rawText(data map[string]any, key string) string {
+	switch v := data[key].(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ";")
+	}
+	return ""
 }
 
 // Source returns the source identifier
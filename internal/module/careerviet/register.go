@@ -0,0 +1,58 @@
+package careerviet
+
+import (
+	"regexp"
+	"time"
+
+	extractor2 "github.com/project-tktt/go-crawler/internal/common/extractor"
+	sitemapfetch "github.com/project-tktt/go-crawler/internal/common/sitemap"
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/module"
+	"github.com/project-tktt/go-crawler/internal/module/sitemap"
+)
+
+func init() {
+	module.Register(module.Registration{
+		Source:   domain.SourceCareerViet,
+		Schedule: "0 */6 * * *", // Every 6 hours (detail pages are slow)
+		Factory: func(deps module.Deps) (module.Crawler, error) {
+			deps.LimitHosts(domain.SourceCareerViet, "careerviet.vn")
+			ext := NewDefaultExtractor(extractor2.ExtractorConfig{
+				UserAgent: deps.Config.Crawler.UserAgent,
+				HTTP:      deps.HTTP(domain.SourceCareerViet),
+			})
+			return NewCrawler(ext, Config{
+				MaxPages:     50,
+				RequestDelay: deps.Config.Crawler.RequestDelay,
+			}).WithDedup(deps.Dedup), nil
+		},
+	})
+
+	// Sitemap discovery runs next to the listing crawler and only fetches
+	// job pages whose <lastmod> changed
+	module.Register(module.Registration{
+		Source:   sitemap.SourceName(domain.SourceCareerViet),
+		Site:     domain.SourceCareerViet,
+		Schedule: "30 */6 * * *", // Every 6 hours, offset from the listing crawl
+		Factory: func(deps module.Deps) (module.Crawler, error) {
+			deps.LimitHosts(domain.SourceCareerViet, "careerviet.vn")
+			ext := NewDefaultExtractor(extractor2.ExtractorConfig{
+				UserAgent: deps.Config.Crawler.UserAgent,
+				HTTP:      deps.HTTP(domain.SourceCareerViet),
+			})
+			listing := NewCrawler(ext, Config{RequestDelay: deps.Config.Crawler.RequestDelay})
+			return sitemap.NewCrawler(sitemap.Config{
+				Source:  domain.SourceCareerViet,
+				SiteURL: BaseURL,
+				Sitemap: sitemapfetch.Config{
+					UserAgent:     deps.Config.Crawler.UserAgent,
+					HTTP:          deps.HTTP(domain.SourceCareerViet),
+					SitemapFilter: regexp.MustCompile(`(?i)job|viec-lam`),
+				},
+				JobURLPattern: regexp.MustCompile(`careerviet\.vn/vi/tim-viec-lam/[^/]+\.([A-Z0-9]+)\.html`),
+				MaxAge:        7 * 24 * time.Hour,
+				RequestDelay:  deps.Config.Crawler.RequestDelay,
+			}, deps.Dedup, listing.FetchDetail), nil
+		},
+	})
+}
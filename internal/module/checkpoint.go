@@ -0,0 +1,107 @@
+package module
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Position is how far a crawler has got within a run
+type Position struct {
+	// Page is the last fully processed page (1-based, 0 = none)
+	Page int `json:"page"`
+	// Cursor is an opaque token for cursor-paginated APIs
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// Resumable is implemented by crawlers that can report and restore their position
+type Resumable interface {
+	// Position returns the page currently handed to the JobHandler
+	Position() Position
+	// Resume makes the next CrawlWithCallback continue after pos
+	Resume(pos Position)
+}
+
+// Checkpoint is the persisted state of an in-progress run
+type Checkpoint struct {
+	Source    string    `json:"source"`
+	RunID     string    `json:"run_id"`
+	Position  Position  `json:"position"`
+	Stats     Stats     `json:"stats"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CheckpointStore keeps one checkpoint per source in Redis
+// Checkpoints expire after ttl so a stale run is never resumed
+type CheckpointStore struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// NewCheckpointStore creates a Redis-backed checkpoint store
+func NewCheckpointStore(client *redis.Client, ttl time.Duration) *CheckpointStore {
+	if ttl <= 0 {
+		ttl = 12 * time.Hour
+	}
+	return &CheckpointStore{
+		client: client,
+		prefix: "crawler:checkpoint",
+		ttl:    ttl,
+	}
+}
+
+// Load returns the checkpoint for a source, or nil when there is none
+func (s *CheckpointStore) Load(ctx context.Context, source string) (*Checkpoint, error) {
+	data, err := s.client.Get(ctx, s.key(source)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis get: %w", err)
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("unmarshal checkpoint: %w", err)
+	}
+	return &cp, nil
+}
+
+// Save stores a checkpoint and refreshes its expiry
+func (s *CheckpointStore) Save(ctx context.Context, cp *Checkpoint) error {
+	cp.UpdatedAt = time.Now()
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+	if err := s.client.Set(ctx, s.key(cp.Source), data, s.ttl).Err(); err != nil {
+		return fmt.Errorf("redis set: %w", err)
+	}
+	return nil
+}
+
+// Clear removes the checkpoint once a run has finished
+func (s *CheckpointStore) Clear(ctx context.Context, source string) error {
+	if err := s.client.Del(ctx, s.key(source)).Err(); err != nil {
+		return fmt.Errorf("redis del: %w", err)
+	}
+	return nil
+}
+
+func (s *CheckpointStore) key(source string) string {
+	return fmt.Sprintf("%s:%s", s.prefix, source)
+}
+
+// NewRunID returns a unique ID for a crawl run (e.g. topdev-20240101T120000-a1b2c3d4)
+func NewRunID(source string) string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%s-%s", source, time.Now().UTC().Format("20060102T150405"), hex.EncodeToString(b))
+}
@@ -18,3 +18,10 @@ type Crawler interface {
 	// Source returns the source identifier
 	Source() domain.JobSource
 }
+
+// DedupSourcer is implemented by crawlers whose jobs share dedup markers with
+// another source's crawler (sitemap discovery next to a listing crawler), so
+// a job found by both is published once
+type DedupSourcer interface {
+	DedupSource() domain.JobSource
+}
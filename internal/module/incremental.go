@@ -0,0 +1,119 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StopReason tells why a crawl run ended
+type StopReason string
+
+const (
+	StopCompleted StopReason = "completed"       // Last page or empty page reached
+	StopMaxPages  StopReason = "max_pages"       // MaxPages cap reached
+	StopUnchanged StopReason = "unchanged_pages" // Incremental early stop
+	StopError     StopReason = "error"           // Page fetch failed
+	StopCancelled StopReason = "cancelled"       // Context cancelled
+	StopBlocked   StopReason = "blocked"         // Source circuit open (ban or challenge)
+)
+
+// ErrStopCrawl is returned by a JobHandler to end pagination early
+var ErrStopCrawl = errors.New("stop crawl")
+
+// StopReporter is implemented by crawlers that report why their last run ended
+type StopReporter interface {
+	StopReason() StopReason
+}
+
+// IncrementalConfig controls early stopping for newest-first listings
+type IncrementalConfig struct {
+	// StopAfterUnchangedPages stops after N consecutive pages where every job
+	// is unchanged (0 disables early stop)
+	StopAfterUnchangedPages int
+	// FullSweepInterval forces a run without early stop when the last full
+	// sweep is older than this (0 = never force)
+	FullSweepInterval time.Duration
+}
+
+// Incremental tracks consecutive unchanged pages within one run and
+// remembers the last full sweep in Redis so it survives restarts
+// A nil *Incremental is valid and never stops early
+type Incremental struct {
+	client *redis.Client
+	key    string
+	config IncrementalConfig
+
+	full           bool
+	unchangedPages int
+}
+
+// NewIncremental creates an incremental tracker for a source
+func NewIncremental(client *redis.Client, source string, cfg IncrementalConfig) *Incremental {
+	return &Incremental{
+		client: client,
+		key:    fmt.Sprintf("crawler:fullsweep:%s", source),
+		config: cfg,
+	}
+}
+
+// Begin starts a run and decides whether it is a full sweep
+func (i *Incremental) Begin(ctx context.Context) {
+	if i == nil {
+		return
+	}
+	i.unchangedPages = 0
+	i.full = i.config.StopAfterUnchangedPages <= 0
+
+	if !i.full && i.config.FullSweepInterval > 0 {
+		last, err := i.client.Get(ctx, i.key).Int64()
+		switch {
+		case err == redis.Nil:
+			i.full = true
+		case err != nil:
+			log.Printf("[Incremental] Read %s: %v", i.key, err)
+		default:
+			i.full = time.Since(time.Unix(last, 0)) >= i.config.FullSweepInterval
+		}
+	}
+
+	if i.full {
+		log.Printf("[Incremental] %s: full sweep", i.key)
+	}
+}
+
+// Full reports whether the current run is a full sweep
+func (i *Incremental) Full() bool {
+	return i == nil || i.full
+}
+
+// Page records a page and returns true when the run should stop early
+func (i *Incremental) Page(total, unchanged int) bool {
+	if i == nil || i.full || total == 0 {
+		return false
+	}
+	if unchanged < total {
+		i.unchangedPages = 0
+		return false
+	}
+	i.unchangedPages++
+	return i.unchangedPages >= i.config.StopAfterUnchangedPages
+}
+
+// Finish records a completed full sweep
+func (i *Incremental) Finish(ctx context.Context, reason StopReason) {
+	if i == nil || !i.full || i.config.FullSweepInterval <= 0 {
+		return
+	}
+	if reason != StopCompleted && reason != StopMaxPages {
+		return // Interrupted sweeps are retried next run
+	}
+	ttl := 2 * i.config.FullSweepInterval
+	if err := i.client.Set(ctx, i.key, time.Now().Unix(), ttl).Err(); err != nil {
+		log.Printf("[Incremental] Write %s: %v", i.key, err)
+	}
+}
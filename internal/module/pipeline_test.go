@@ -0,0 +1,156 @@
+package module_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/common/cleaner"
+	"github.com/project-tktt/go-crawler/internal/common/normalizer"
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/module"
+	"github.com/project-tktt/go-crawler/internal/module/worker"
+	"github.com/project-tktt/go-crawler/internal/queue"
+)
+
+// pagesCrawler hands out fixed pages of jobs
+type pagesCrawler struct {
+	pages [][]*domain.RawJob
+}
+
+func (c *pagesCrawler) Crawl(ctx context.Context) ([]*domain.RawJob, error) {
+	var all []*domain.RawJob
+	err := c.CrawlWithCallback(ctx, func(jobs []*domain.RawJob) error {
+		all = append(all, jobs...)
+		return nil
+	})
+	return all, err
+}
+
+func (c *pagesCrawler) CrawlWithCallback(ctx context.Context, handler module.JobHandler) error {
+	for _, page := range c.pages {
+		if err := handler(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *pagesCrawler) Source() domain.JobSource {
+	return domain.SourceTopCV
+}
+
+// flakyIndexer fails its first bulk requests, then records what it indexes
+type flakyIndexer struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	indexed  map[string]*domain.Job
+}
+
+func (idx *flakyIndexer) BulkIndex(ctx context.Context, jobs []*domain.Job) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.calls++
+	if idx.calls <= idx.failures {
+		return errors.New("cluster unavailable")
+	}
+	for _, job := range jobs {
+		idx.indexed[job.ID] = job
+	}
+	return nil
+}
+
+func (idx *flakyIndexer) ids() []string {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	ids := make([]string, 0, len(idx.indexed))
+	for id := range idx.indexed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// TestMemoryPipeline runs crawler -> runner -> MemoryQueue -> worker in one
+// process without Redis; the first bulk request fails, so its jobs go
+// through the in-memory retrier back onto the queue before being indexed
+func TestMemoryPipeline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	crawler := &pagesCrawler{}
+	var want []string
+	for page := 0; page < 2; page++ {
+		var jobs []*domain.RawJob
+		for i := 0; i < 3; i++ {
+			id := fmt.Sprintf("%d%02d", page+1, i)
+			want = append(want, id)
+			jobs = append(jobs, &domain.RawJob{
+				ID:     id,
+				URL:    "https://www.topcv.vn/viec-lam/job/" + id + ".html",
+				Source: string(domain.SourceTopCV),
+				RawData: map[string]any{
+					"title":   "Job " + id,
+					"company": "Company",
+				},
+			})
+		}
+		crawler.pages = append(crawler.pages, jobs)
+	}
+
+	jobQueue := queue.NewMemoryQueue("jobs:raw", 100, 20*time.Millisecond)
+	retrier := queue.NewMemoryRetrier(jobQueue, nil, queue.RetryConfig{
+		Stage:       "worker",
+		MaxAttempts: 3,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    20 * time.Millisecond,
+		Interval:    5 * time.Millisecond,
+	})
+	idx := &flakyIndexer{failures: 1, indexed: make(map[string]*domain.Job)}
+
+	stats := module.NewRunner(crawler, nil, jobQueue).Run(ctx)
+	if stats.New != len(want) || stats.StopReason != module.StopCompleted {
+		t.Fatalf("run stats = %+v, want %d new jobs and a completed run", stats, len(want))
+	}
+
+	var wg sync.WaitGroup
+	runCtx, stop := context.WithCancel(ctx)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		retrier.RunMover(runCtx)
+	}()
+	go func() {
+		defer wg.Done()
+		w := worker.NewWorker(jobQueue, normalizer.NewNormalizer(), cleaner.NewCleaner(), idx, worker.Config{
+			Concurrency: 1,
+			BatchSize:   10,
+		}).WithRetries(retrier)
+		w.Run(runCtx)
+	}()
+
+	for len(idx.ids()) < len(want) && ctx.Err() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	stop()
+	wg.Wait()
+
+	got := idx.ids()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("indexed %v, want %v", got, want)
+	}
+	if idx.calls < 2 {
+		t.Errorf("bulk requests = %d, want the failed one retried", idx.calls)
+	}
+	if job := idx.indexed[want[0]]; job.Title != "Job "+want[0] || job.Source != string(domain.SourceTopCV) {
+		t.Errorf("indexed job = %+v", job)
+	}
+	if n, _ := retrier.Scheduled(ctx); n != 0 {
+		t.Errorf("%d jobs still scheduled for retry", n)
+	}
+}
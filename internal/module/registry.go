@@ -0,0 +1,215 @@
+package module
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/common/breaker"
+	"github.com/project-tktt/go-crawler/internal/common/cassette"
+	"github.com/project-tktt/go-crawler/internal/common/dedup"
+	"github.com/project-tktt/go-crawler/internal/common/fetch"
+	"github.com/project-tktt/go-crawler/internal/common/proxy"
+	"github.com/project-tktt/go-crawler/internal/common/ratelimit"
+	"github.com/project-tktt/go-crawler/internal/common/robots"
+	"github.com/project-tktt/go-crawler/internal/config"
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/queue"
+	"github.com/redis/go-redis/v9"
+)
+
+// Deps holds the shared dependencies handed to crawler factories
+type Deps struct {
+	Config *config.Config
+	Redis  *redis.Client
+	Dedup  *dedup.Deduplicator
+	// Limiter is the shared per-host rate limiter (nil = unlimited)
+	Limiter *ratelimit.Limiter
+	// Proxies is the shared proxy pool (nil = direct connections)
+	Proxies *proxy.Pool
+	// Robots caches robots.txt per host (nil = robots.txt ignored)
+	Robots *robots.Cache
+	// Cassettes records or replays HTTP traffic per source (nil = live only)
+	Cassettes *cassette.Recorder
+	// Breakers pause a source after ban signals, shared through Redis (nil = off)
+	Breakers *breaker.Group
+}
+
+// NewDeps builds the shared dependencies from config
+func NewDeps(cfg *config.Config, rdb *redis.Client) (Deps, error) {
+	proxies, err := proxy.Load(proxy.Config{
+		URLs:     append([]string{cfg.Crawler.ProxyURL}, cfg.Crawler.ProxyList...),
+		File:     cfg.Crawler.ProxyFile,
+		Rotation: proxy.Rotation(cfg.Crawler.ProxyRotation),
+		Cooldown: cfg.Crawler.ProxyCooldown,
+	})
+	if err != nil {
+		return Deps{}, fmt.Errorf("load proxies: %w", err)
+	}
+
+	var breakers *breaker.Group
+	if cfg.Crawler.BreakerThreshold > 0 {
+		breakers = breaker.NewGroup(rdb, breaker.Config{
+			Threshold:   cfg.Crawler.BreakerThreshold,
+			Cooldown:    cfg.Crawler.BreakerCooldown,
+			MaxCooldown: cfg.Crawler.BreakerMaxCooldown,
+		})
+	}
+
+	return Deps{
+		Config:  cfg,
+		Redis:   rdb,
+		Dedup:   dedup.NewDeduplicator(rdb, "job:seen", 30*24*time.Hour),
+		Limiter: ratelimit.NewLimiter(rdb, ratelimit.Limit{Rate: cfg.Crawler.RateLimit.RPS, Burst: cfg.Crawler.RateLimit.Burst}),
+		Proxies: proxies,
+		Robots:  robots.NewCache(robots.Config{}),
+		Cassettes: cassette.NewRecorder(cassette.Config{
+			Mode: cassette.Mode(strings.ToLower(cfg.Crawler.HTTPMode)),
+			Dir:  cfg.Crawler.CassetteDir,
+		}),
+		Breakers: breakers,
+	}, nil
+}
+
+// LimitHosts applies the source's rate limit (CRAWLER_RATE_LIMIT_<SOURCE>,
+// else CRAWLER_RATE_LIMIT) to the hosts it fetches from
+func (d Deps) LimitHosts(source domain.JobSource, hosts ...string) {
+	if d.Limiter == nil {
+		return
+	}
+	limit, ok := d.Config.Crawler.SourceRateLimits[string(source)]
+	if !ok {
+		limit = d.Config.Crawler.RateLimit
+	}
+	for _, host := range hosts {
+		d.Limiter.SetLimit(host, ratelimit.Limit{Rate: limit.RPS, Burst: limit.Burst})
+	}
+}
+
+// Incremental returns an early-stop tracker for a source configured from CRAWLER_* settings
+func (d Deps) Incremental(source domain.JobSource) *Incremental {
+	return NewIncremental(d.Redis, string(source), IncrementalConfig{
+		StopAfterUnchangedPages: d.Config.Crawler.StopAfterUnchangedPages,
+		FullSweepInterval:       d.Config.Crawler.FullSweepInterval,
+	})
+}
+
+// RetryPolicy returns the source's retry budget (CRAWLER_MAX_RETRIES_<SOURCE>,
+// else CRAWLER_MAX_RETRIES)
+func (d Deps) RetryPolicy(source domain.JobSource) fetch.Policy {
+	retries, ok := d.Config.Crawler.SourceMaxRetries[string(source)]
+	if !ok {
+		retries = d.Config.Crawler.MaxRetries
+	}
+	return fetch.Policy{MaxRetries: retries}
+}
+
+// HTTP returns the fetch layers (rate limit, retry budget, proxy pool,
+// robots.txt policy, cassette, circuit breaker) for a source
+func (d Deps) HTTP(source domain.JobSource) fetch.Options {
+	return fetch.Options{
+		Limiter:  d.Limiter,
+		Retry:    d.RetryPolicy(source),
+		Proxies:  d.Proxies,
+		Robots:   d.Robots.Policy(string(source), d.RobotsMode(source)),
+		Cassette: d.Cassettes.Cassette(string(source)),
+		Breaker:  d.Breakers.Breaker(string(source)),
+	}
+}
+
+// Publisher returns a publisher for a queue; the job queue (REDIS_JOB_QUEUE)
+// is a stream when REDIS_JOB_QUEUE_BACKEND=stream, other queues are lists
+func (d Deps) Publisher(queueName string) *queue.RedisPublisher {
+	redisCfg := d.Config.Redis
+	if queueName == redisCfg.JobQueue && strings.EqualFold(redisCfg.JobQueueBackend, "stream") {
+		return queue.NewStreamPublisher(d.Redis, queueName, redisCfg.JobQueueMaxLen)
+	}
+	return queue.NewPublisher(d.Redis, queueName)
+}
+
+// RobotsMode returns the source's robots.txt mode (CRAWLER_ROBOTS_<SOURCE>,
+// else CRAWLER_ROBOTS)
+func (d Deps) RobotsMode(source domain.JobSource) robots.Mode {
+	mode, ok := d.Config.Crawler.SourceRobots[string(source)]
+	if !ok {
+		mode = d.Config.Crawler.Robots
+	}
+	return robots.Mode(strings.ToLower(mode))
+}
+
+// Factory builds a Crawler from shared dependencies
+type Factory func(deps Deps) (Crawler, error)
+
+// Registration describes how a source is built and scheduled
+type Registration struct {
+	Source  domain.JobSource
+	Factory Factory
+	// Schedule is the default cron schedule (e.g. "0 */6 * * *")
+	Schedule string
+	// Queue is the output queue; empty uses Config.Redis.JobQueue
+	Queue string
+	// SelfPublishing crawlers run dedup and publish to Queue themselves;
+	// the runner only counts the jobs handed to its callback
+	SelfPublishing bool
+	// Incremental marks newest-first listings where the runner may stop after
+	// fully unchanged pages (self-publishing crawlers handle this themselves)
+	Incremental bool
+	// Site is the source whose fetch layers the crawler shares (e.g. the
+	// sitemap crawler of topcv uses topcv's circuit); empty = Source
+	Site domain.JobSource
+}
+
+// SiteSource returns the source whose circuit breaker governs the crawler
+func (r Registration) SiteSource() domain.JobSource {
+	if r.Site != "" {
+		return r.Site
+	}
+	return r.Source
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[domain.JobSource]Registration)
+)
+
+// Register makes a source available to the multi-source crawler
+// Source packages call it from init(); it panics on duplicate or invalid registrations
+func Register(r Registration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if r.Source == "" {
+		panic("module: Register with empty source")
+	}
+	if r.Factory == nil {
+		panic(fmt.Sprintf("module: Register %s with nil factory", r.Source))
+	}
+	if _, dup := registry[r.Source]; dup {
+		panic(fmt.Sprintf("module: Register called twice for source %s", r.Source))
+	}
+	registry[r.Source] = r
+}
+
+// Lookup returns the registration for a source
+func Lookup(source domain.JobSource) (Registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	r, ok := registry[source]
+	return r, ok
+}
+
+// Sources returns all registered sources in sorted order
+func Sources() []domain.JobSource {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	sources := make([]domain.JobSource, 0, len(registry))
+	for s := range registry {
+		sources = append(sources, s)
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i] < sources[j] })
+	return sources
+}
@@ -0,0 +1,253 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/common/breaker"
+	"github.com/project-tktt/go-crawler/internal/common/dedup"
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/queue"
+)
+
+// Stats holds counters for a single crawl cycle
+type Stats struct {
+	// RunID identifies the run (kept across resumes)
+	RunID     string
+	Total     int
+	New       int
+	Updated   int
+	Unchanged int
+	// StopReason tells which rule ended the run
+	StopReason StopReason
+}
+
+// Runner drives a crawler through dedup and publishes new/updated jobs
+type Runner struct {
+	crawler Crawler
+	// dedup skips unchanged jobs (nil = publish every job)
+	dedup     *dedup.Deduplicator
+	publisher queue.Publisher // nil for self-publishing crawlers
+	// incremental stops after fully unchanged pages (nil = always full)
+	incremental *Incremental
+	// checkpoints persist the position of Resumable crawlers (nil = disabled)
+	checkpoints *CheckpointStore
+	// breaker skips runs while the source is paused (nil = always run)
+	breaker *breaker.Breaker
+}
+
+// NewRunner creates a runner for a crawler
+// Pass a nil publisher for crawlers that dedup and publish internally, and a
+// nil deduplicator to publish every job (in-memory pipelines and tests)
+func NewRunner(c Crawler, deduplicator *dedup.Deduplicator, publisher queue.Publisher) *Runner {
+	return &Runner{
+		crawler:   c,
+		dedup:     deduplicator,
+		publisher: publisher,
+	}
+}
+
+// WithIncremental enables early stop for crawlers whose listings are newest-first
+// Self-publishing crawlers track unchanged pages themselves
+func (r *Runner) WithIncremental(inc *Incremental) *Runner {
+	r.incremental = inc
+	return r
+}
+
+// WithCheckpoints saves the position of Resumable crawlers after every page
+// so an interrupted run continues where it stopped
+func (r *Runner) WithCheckpoints(store *CheckpointStore) *Runner {
+	r.checkpoints = store
+	return r
+}
+
+// WithBreaker skips runs while the source's circuit is open
+func (r *Runner) WithBreaker(b *breaker.Breaker) *Runner {
+	r.breaker = b
+	return r
+}
+
+// Run executes one crawl cycle and returns its counters
+func (r *Runner) Run(ctx context.Context) Stats {
+	source := string(r.crawler.Source())
+	if status, err := r.breaker.Allow(ctx); err != nil {
+		log.Printf("Crawler %s: skipped, circuit open until %s (%s)", source, status.OpenUntil.Format(time.RFC3339), status.Reason)
+		return Stats{StopReason: StopBlocked}
+	}
+	log.Printf("Running crawler: %s", source)
+
+	r.incremental.Begin(ctx)
+
+	cp := r.beginCheckpoint(ctx, source)
+	stats := cp.Stats
+	stats.RunID = cp.RunID
+	// Every job published in this run carries its run ID and trace
+	ctx = queue.WithTraceParent(queue.WithRunID(ctx, cp.RunID), queue.NewTraceParent())
+
+	// Use streaming callback to process each page immediately
+	err := r.crawler.CrawlWithCallback(ctx, func(jobs []*domain.RawJob) error {
+		err := r.processPage(ctx, source, jobs, &stats)
+		r.saveCheckpoint(ctx, cp, stats)
+		return err
+	})
+
+	if err != nil {
+		log.Printf("Crawler %s error: %v", source, err)
+		if errors.Is(err, breaker.ErrOpen) || errors.Is(err, breaker.ErrBanned) {
+			stats.StopReason = StopBlocked
+		}
+	}
+
+	if stats.StopReason == "" {
+		stats.StopReason = r.stopReason(err)
+	}
+	r.incremental.Finish(ctx, stats.StopReason)
+	r.finishCheckpoint(ctx, source, stats.StopReason)
+
+	log.Printf("Crawler %s finished cycle %s: %d total, %d new, %d updated, %d unchanged (stop: %s)", source, stats.RunID, stats.Total, stats.New, stats.Updated, stats.Unchanged, stats.StopReason)
+	return stats
+}
+
+// stopReason tells why a run not ended by a runner rule stopped: crawlers
+// that stop on their own report the rule, other runs are judged by their error
+// so an interrupted run keeps its checkpoint
+func (r *Runner) stopReason(err error) StopReason {
+	if reporter, ok := r.crawler.(StopReporter); ok && reporter.StopReason() != "" {
+		return reporter.StopReason()
+	}
+	switch {
+	case err == nil || errors.Is(err, ErrStopCrawl):
+		return StopCompleted
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return StopCancelled
+	}
+	return StopError
+}
+
+// processPage dedups one page of jobs and publishes new/updated ones
+func (r *Runner) processPage(ctx context.Context, source string, jobs []*domain.RawJob, stats *Stats) error {
+	if r.publisher == nil {
+		// Crawler already deduped and published these jobs
+		stats.Total += len(jobs)
+		return nil
+	}
+
+	markers := source
+	if ds, ok := r.crawler.(DedupSourcer); ok {
+		markers = string(ds.DedupSource())
+	}
+
+	pageNew, pageUpdated := 0, 0
+	for _, job := range jobs {
+		jobID := job.ID
+		if jobID == "" {
+			jobID = job.URL
+		}
+
+		// Smart dedup: check if new, updated, or unchanged
+		result, err := r.checkJob(ctx, markers, jobID, job.LastUpdatedOn)
+		if err != nil {
+			log.Printf("Dedup check error: %v", err)
+			continue
+		}
+
+		switch result {
+		case dedup.ResultUnchanged:
+			stats.Unchanged++
+			continue
+		case dedup.ResultUpdated:
+			pageUpdated++
+			log.Printf("[%s] Job %s updated, re-processing", source, jobID)
+		case dedup.ResultNew:
+			pageNew++
+		}
+
+		// Publish to queue (new or updated)
+		if err := r.publisher.Publish(ctx, job); err != nil {
+			log.Printf("Publish error: %v", err)
+			continue
+		}
+
+		// Mark as seen with TTL based on expiredOn
+		if r.dedup == nil {
+			continue
+		}
+		if err := r.dedup.MarkSeenWithTTL(ctx, markers, jobID, job.LastUpdatedOn, job.ExpiredOn); err != nil {
+			log.Printf("Mark seen error: %v", err)
+		}
+	}
+	stats.New += pageNew
+	stats.Updated += pageUpdated
+	stats.Total += len(jobs)
+	pageUnchanged := len(jobs) - pageNew - pageUpdated
+	log.Printf("Crawler %s: page - %d new, %d updated, %d unchanged", source, pageNew, pageUpdated, pageUnchanged)
+
+	if r.incremental.Page(len(jobs), pageUnchanged) {
+		stats.StopReason = StopUnchanged
+		return ErrStopCrawl
+	}
+	return nil
+}
+
+// checkJob classifies a job against the dedup markers; every job is new
+// without a deduplicator
+func (r *Runner) checkJob(ctx context.Context, source, jobID, lastUpdatedOn string) (dedup.CheckResult, error) {
+	if r.dedup == nil {
+		return dedup.ResultNew, nil
+	}
+	return r.dedup.CheckJob(ctx, source, jobID, lastUpdatedOn)
+}
+
+// beginCheckpoint resumes an interrupted run or starts a new one
+func (r *Runner) beginCheckpoint(ctx context.Context, source string) *Checkpoint {
+	fresh := &Checkpoint{Source: source, RunID: NewRunID(source), StartedAt: time.Now()}
+
+	resumable, ok := r.crawler.(Resumable)
+	if !ok || r.checkpoints == nil {
+		return fresh
+	}
+
+	cp, err := r.checkpoints.Load(ctx, source)
+	if err != nil {
+		log.Printf("Crawler %s: load checkpoint: %v", source, err)
+	}
+	if cp == nil {
+		resumable.Resume(Position{})
+		return fresh
+	}
+
+	log.Printf("Crawler %s: resuming run %s after page %d (started %s)", source, cp.RunID, cp.Position.Page, cp.StartedAt.Format(time.RFC3339))
+	cp.Stats.StopReason = ""
+	resumable.Resume(cp.Position)
+	return cp
+}
+
+// saveCheckpoint records the crawler position after a page has been handled
+func (r *Runner) saveCheckpoint(ctx context.Context, cp *Checkpoint, stats Stats) {
+	resumable, ok := r.crawler.(Resumable)
+	if !ok || r.checkpoints == nil {
+		return
+	}
+	cp.Position = resumable.Position()
+	cp.Stats = stats
+	// A page handled during shutdown must still be recorded
+	if err := r.checkpoints.Save(context.WithoutCancel(ctx), cp); err != nil {
+		log.Printf("Crawler %s: save checkpoint: %v", cp.Source, err)
+	}
+}
+
+// finishCheckpoint clears the checkpoint unless the run was interrupted
+func (r *Runner) finishCheckpoint(ctx context.Context, source string, reason StopReason) {
+	if _, ok := r.crawler.(Resumable); !ok || r.checkpoints == nil {
+		return
+	}
+	if reason == StopCancelled || reason == StopError || reason == StopBlocked {
+		log.Printf("Crawler %s: run interrupted (%s), checkpoint kept for resume", source, reason)
+		return
+	}
+	if err := r.checkpoints.Clear(context.WithoutCancel(ctx), source); err != nil {
+		log.Printf("Crawler %s: clear checkpoint: %v", source, err)
+	}
+}
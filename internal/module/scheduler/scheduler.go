@@ -0,0 +1,145 @@
+// Package scheduler runs registered crawlers on their cron schedules; it is
+// shared by cmd/crawler and the per-source commands
+package scheduler
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/config"
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/module"
+	"github.com/project-tktt/go-crawler/internal/queue"
+	"github.com/redis/go-redis/v9"
+	"github.com/robfig/cron/v3"
+)
+
+// Main runs the crawlers of CRAWLER_SOURCES on their cron schedules until
+// SIGINT or SIGTERM; without it, sources (default: every registered source)
+// Source packages must be imported so they register themselves
+func Main(service string, sources ...domain.JobSource) {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.Printf("Starting %s Service", service)
+
+	// Load configuration
+	cfg := config.Load()
+
+	// Initialize Redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Test Redis connection
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		log.Fatalf("Redis connection failed: %v", err)
+	}
+	log.Println("Redis connected")
+
+	// Initialize Components
+	deps, err := module.NewDeps(cfg, rdb)
+	if err != nil {
+		log.Fatalf("Failed to initialize dependencies: %v", err)
+	}
+	checkpoints := module.NewCheckpointStore(rdb, cfg.Crawler.CheckpointTTL)
+
+	// Resolve sources: CRAWLER_SOURCES, the command's own or every registered source
+	if len(cfg.Crawler.Sources) > 0 {
+		sources = nil
+		for _, s := range cfg.Crawler.Sources {
+			sources = append(sources, domain.JobSource(s))
+		}
+	} else if len(sources) == 0 {
+		sources = module.Sources()
+	}
+
+	// Setup cron scheduler; overlapping runs of the same source are skipped
+	logger := cron.VerbosePrintfLogger(log.Default())
+	c := cron.New(cron.WithLogger(logger), cron.WithChain(cron.SkipIfStillRunning(logger)))
+
+	for _, source := range sources {
+		reg, ok := module.Lookup(source)
+		if !ok {
+			log.Fatalf("Unknown source %q (registered: %v)", source, module.Sources())
+		}
+
+		crawler, err := reg.Factory(deps)
+		if err != nil {
+			log.Fatalf("Failed to create %s crawler: %v", source, err)
+		}
+
+		// Self-publishing crawlers push to their own queue
+		var publisher queue.Publisher
+		if !reg.SelfPublishing {
+			queueName := reg.Queue
+			if queueName == "" {
+				queueName = cfg.Redis.JobQueue
+			}
+			publisher = deps.Publisher(queueName)
+		}
+		runner := module.NewRunner(crawler, deps.Dedup, publisher).
+			WithCheckpoints(checkpoints).
+			WithBreaker(deps.Breakers.Breaker(string(reg.SiteSource())))
+		if reg.Incremental && !reg.SelfPublishing {
+			runner.WithIncremental(deps.Incremental(source))
+		}
+
+		schedule := scheduleFor(reg)
+		job := cron.FuncJob(func() {
+			start := time.Now()
+			runner.Run(ctx)
+			log.Printf("[Cron] Crawler %s finished in %v", source, time.Since(start))
+		})
+
+		if _, err := c.AddJob(schedule, job); err != nil {
+			log.Fatalf("Failed to add cron job for %s: %v", source, err)
+		}
+		log.Printf("Cron scheduled: %s -> %s", source, schedule)
+	}
+
+	// Start cron scheduler
+	c.Start()
+
+	// Run every source immediately on startup (through the same skip-if-running chain)
+	for _, entry := range c.Entries() {
+		go entry.WrappedJob.Run()
+	}
+	log.Printf("Cron started with %d sources", len(sources))
+
+	// Handle graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// Wait for shutdown signal
+	<-sigChan
+	log.Println("Shutdown signal received, stopping...")
+
+	// Stop cron scheduler and cancel running crawls
+	cronCtx := c.Stop()
+	cancel()
+	<-cronCtx.Done()
+
+	time.Sleep(1 * time.Second)
+	log.Println("Graceful shutdown complete")
+}
+
+// scheduleFor returns the cron schedule for a source
+// CRAWLER_SCHEDULE_<SOURCE> (e.g. CRAWLER_SCHEDULE_TOPDEV) overrides the registered default
+func scheduleFor(reg module.Registration) string {
+	if s := os.Getenv("CRAWLER_SCHEDULE_" + strings.ToUpper(string(reg.Source))); s != "" {
+		return s
+	}
+	if reg.Schedule != "" {
+		return reg.Schedule
+	}
+	return "0 * * * *"
+}
@@ -0,0 +1,276 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/common/breaker"
+	"github.com/project-tktt/go-crawler/internal/common/dedup"
+	"github.com/project-tktt/go-crawler/internal/common/sitemap"
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/module"
+)
+
+// DetailFunc fetches a single job detail page as a RawJob
+type DetailFunc func(ctx context.Context, jobURL string) (*domain.RawJob, error)
+
+// Config holds sitemap discovery settings for one source
+type Config struct {
+	// Source is the job source stored in RawJob.Source (e.g. topcv)
+	Source domain.JobSource
+	// SiteURL is the site root used to locate robots.txt
+	SiteURL string
+	// Sitemap discovery options (explicit sitemaps, index filter, user agent)
+	Sitemap sitemap.Config
+	// JobURLPattern keeps only job detail URLs; its first capture group is the job ID
+	JobURLPattern *regexp.Regexp
+	// MaxAge skips entries whose lastmod is older than this (0 = no limit)
+	MaxAge time.Duration
+	// MaxJobs caps detail fetches per run (default 500)
+	MaxJobs int
+	// BatchSize is the number of jobs handed to the handler at once (default 20)
+	BatchSize int
+	// RequestDelay is the base delay between detail fetches
+	RequestDelay time.Duration
+}
+
+// Crawler discovers job URLs from sitemaps and fetches only new or changed ones
+// It runs next to a source's listing crawler under its own name (<source>_sitemap)
+// for the registry and cron; the jobs it fetches go through the runner's dedup
+// under the source itself, so a job found by both crawlers is published once
+// The <lastmod> of fetched URLs is kept apart, under <source>_sitemap, only to
+// skip fetching pages that did not change since the last run
+type Crawler struct {
+	config  Config
+	fetcher *sitemap.Fetcher
+	dedup   *dedup.Deduplicator
+	detail  DetailFunc
+}
+
+// NewCrawler creates a new sitemap-driven crawler
+func NewCrawler(cfg Config, deduplicator *dedup.Deduplicator, detail DetailFunc) *Crawler {
+	if cfg.MaxJobs <= 0 {
+		cfg.MaxJobs = 500
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 20
+	}
+	if cfg.RequestDelay <= 0 {
+		cfg.RequestDelay = 2 * time.Second // Base delay, will add random 0-2000ms
+	}
+	return &Crawler{
+		config:  cfg,
+		fetcher: sitemap.NewFetcher(cfg.Sitemap),
+		dedup:   deduplicator,
+		detail:  detail,
+	}
+}
+
+// SourceName returns the registry name of a source's sitemap crawler
+func SourceName(source domain.JobSource) domain.JobSource {
+	return source + "_sitemap"
+}
+
+// Crawl fetches all new and changed jobs from the sitemaps
+func (c *Crawler) Crawl(ctx context.Context) ([]*domain.RawJob, error) {
+	var allJobs []*domain.RawJob
+	err := c.CrawlWithCallback(ctx, func(jobs []*domain.RawJob) error {
+		allJobs = append(allJobs, jobs...)
+		return nil
+	})
+	return allJobs, err
+}
+
+// CrawlWithCallback discovers job URLs, skips those whose lastmod has not
+// changed since they were last fetched, fetches the rest and calls handler
+// every BatchSize jobs
+// Jobs keep the LastUpdatedOn of their page, the marker the listing crawler
+// stores too; the lastmod is recorded once the handler took the batch
+func (c *Crawler) CrawlWithCallback(ctx context.Context, handler module.JobHandler) error {
+	tag := fmt.Sprintf("[Sitemap:%s]", c.config.Source)
+	lastMods := string(c.Source())
+
+	entries, err := c.fetcher.Discover(ctx, c.config.SiteURL)
+	if err != nil {
+		return fmt.Errorf("discover sitemaps: %w", err)
+	}
+
+	// Newest first so MaxJobs keeps the freshest changes
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastMod.After(entries[j].LastMod)
+	})
+
+	var cutoff time.Time
+	if c.config.MaxAge > 0 {
+		cutoff = time.Now().Add(-c.config.MaxAge)
+	}
+
+	var batch []*domain.RawJob
+	var batchMods []lastMod
+	matched, unchanged, fetched, failed := 0, 0, 0, 0
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := handler(batch); err != nil {
+			log.Printf("%s Handler error: %v", tag, err)
+		} else {
+			for i, mod := range batchMods {
+				if mod.marker == "" {
+					continue
+				}
+				// Not cancelled with ctx: the batch has been handled
+				err := c.dedup.MarkSeenWithTTL(context.WithoutCancel(ctx), lastMods, mod.jobID, mod.marker, batch[i].ExpiredOn)
+				if err != nil {
+					log.Printf("%s Mark lastmod error: %v", tag, err)
+				}
+			}
+		}
+		batch, batchMods = nil, nil
+	}
+
+	for _, entry := range entries {
+		if fetched >= c.config.MaxJobs {
+			log.Printf("%s Reached MaxJobs (%d)", tag, c.config.MaxJobs)
+			break
+		}
+		select {
+		case <-ctx.Done():
+			flush()
+			return ctx.Err()
+		default:
+		}
+
+		jobID, ok := c.jobID(entry.Loc)
+		if !ok {
+			continue
+		}
+		if !cutoff.IsZero() && !entry.LastMod.IsZero() && entry.LastMod.Before(cutoff) {
+			continue
+		}
+		matched++
+
+		// Skip the detail fetch when lastmod matches the last fetch
+		marker := lastModMarker(entry.LastMod)
+		if marker != "" {
+			result, err := c.dedup.CheckJob(ctx, lastMods, jobID, marker)
+			if err != nil {
+				log.Printf("%s Dedup check error: %v", tag, err)
+				continue
+			}
+			if result == dedup.ResultUnchanged {
+				unchanged++
+				continue
+			}
+		}
+
+		job, err := c.detail(ctx, entry.Loc)
+		fetched++
+		if errors.Is(err, breaker.ErrOpen) {
+			// Source is paused; the next run picks up the remaining URLs
+			flush()
+			return fmt.Errorf("extract %s: %w", entry.Loc, err)
+		}
+		if err != nil {
+			failed++
+			log.Printf("%s Error extracting %s: %v", tag, entry.Loc, err)
+		} else {
+			if job.ID == "" {
+				job.ID = jobID
+			}
+			job.Source = string(c.config.Source)
+			if job.ExpiredOn.IsZero() {
+				job.ExpiredOn = time.Now().Add(30 * 24 * time.Hour) // Default 30 days
+			}
+			if job.LastUpdatedOn == "" && c.tracked(ctx, job) {
+				// A page without a marker of its own would overwrite the listing
+				// crawler's, which already tracks the job's updates
+				unchanged++
+				if marker != "" {
+					if err := c.dedup.MarkSeenWithTTL(ctx, lastMods, jobID, marker, job.ExpiredOn); err != nil {
+						log.Printf("%s Mark lastmod error: %v", tag, err)
+					}
+				}
+			} else {
+				if job.LastUpdatedOn == "" {
+					job.LastUpdatedOn = marker
+				}
+				batch = append(batch, job)
+				batchMods = append(batchMods, lastMod{jobID: jobID, marker: marker})
+				if len(batch) >= c.config.BatchSize {
+					flush()
+				}
+			}
+		}
+
+		// Random delay: base delay + random 0-2000ms
+		randomDelay := c.config.RequestDelay + time.Duration(rand.Intn(2000))*time.Millisecond
+		time.Sleep(randomDelay)
+	}
+	flush()
+
+	log.Printf("%s %d urls, %d job urls, %d unchanged, %d fetched (%d failed)",
+		tag, len(entries), matched, unchanged, fetched, failed)
+	return nil
+}
+
+// tracked reports whether the listing crawler of the source marked the job
+// with a marker of its own (not a lastmod this crawler stored)
+func (c *Crawler) tracked(ctx context.Context, job *domain.RawJob) bool {
+	marker, err := c.dedup.Marker(ctx, job.Source, job.ID)
+	if err != nil {
+		log.Printf("[Sitemap:%s] Dedup check error: %v", c.config.Source, err)
+		return false
+	}
+	return marker != "" && !strings.HasPrefix(marker, lastModPrefix)
+}
+
+// jobID returns the job ID for a job URL, or false when the URL is not a job page
+func (c *Crawler) jobID(loc string) (string, bool) {
+	if c.config.JobURLPattern == nil {
+		return loc, true
+	}
+	m := c.config.JobURLPattern.FindStringSubmatch(loc)
+	if m == nil {
+		return "", false
+	}
+	if len(m) > 1 && m[1] != "" {
+		return m[1], true
+	}
+	return loc, true
+}
+
+// lastModPrefix marks dedup values that are sitemap lastmods
+const lastModPrefix = "lastmod:"
+
+// lastMod is the sitemap lastmod of a fetched job
+type lastMod struct {
+	jobID  string
+	marker string
+}
+
+// lastModMarker formats lastmod for dedup; empty when the sitemap has none
+func lastModMarker(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return lastModPrefix + t.UTC().Format(time.RFC3339)
+}
+
+// Source returns the registry name (<source>_sitemap)
+func (c *Crawler) Source() domain.JobSource {
+	return SourceName(c.config.Source)
+}
+
+// DedupSource returns the source whose dedup markers the fetched jobs share
+func (c *Crawler) DedupSource() domain.JobSource {
+	return c.config.Source
+}
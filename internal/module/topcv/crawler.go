@@ -0,0 +1,334 @@
+package topcv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/common/dateutil"
+	"github.com/project-tktt/go-crawler/internal/common/dedup"
+	"github.com/project-tktt/go-crawler/internal/common/extractor"
+	"github.com/project-tktt/go-crawler/internal/common/fetch"
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/module"
+)
+
+const (
+	BaseURL    = "https://www.topcv.vn"
+	ListingURL = "https://www.topcv.vn/viec-lam"
+)
+
+var jobIDPattern = regexp.MustCompile(`/(\d+)\.html`)
+
+// Crawler implements job crawling for TopCV
+// Listing pages are scraped for job links, detail pages are parsed from __NEXT_DATA__
+type Crawler struct {
+	extractor extractor.Extractor
+	config    Config
+	dedup     *dedup.Deduplicator // Skips detail pages of tracked jobs (nil = fetch all)
+}
+
+// NewCrawler creates a new TopCV crawler
+func NewCrawler(ext extractor.Extractor, cfg Config) *Crawler {
+	if cfg.MaxPages <= 0 {
+		cfg.MaxPages = 10
+	}
+	if cfg.RequestDelay <= 0 {
+		cfg.RequestDelay = 2 * time.Second // Base delay, will add random 0-2000ms
+	}
+	return &Crawler{
+		extractor: ext,
+		config:    cfg,
+	}
+}
+
+// WithDedup fetches detail pages only for jobs the runner has not published
+// yet; listing pages carry no update marker, so changes to tracked jobs are
+// picked up by the topcv_sitemap crawler from <lastmod>
+func (c *Crawler) WithDedup(d *dedup.Deduplicator) *Crawler {
+	c.dedup = d
+	return c
+}
+
+// NewDefaultExtractor creates the Colly extractor configured for TopCV pages
+func NewDefaultExtractor(cfg extractor.ExtractorConfig) extractor.Extractor {
+	return extractor.NewCollyExtractor(domain.SourceTopCV, extractor.Selectors{
+		JobItem:        ".job-item-search-result",
+		JobLink:        "h3.title a",
+		NextDataScript: "script#__NEXT_DATA__",
+	}, cfg)
+}
+
+// Crawl fetches job listings from TopCV
+func (c *Crawler) Crawl(ctx context.Context) ([]*domain.RawJob, error) {
+	var allJobs []*domain.RawJob
+	err := c.CrawlWithCallback(ctx, func(jobs []*domain.RawJob) error {
+		allJobs = append(allJobs, jobs...)
+		return nil
+	})
+	return allJobs, err
+}
+
+// CrawlWithCallback fetches jobs page by page and calls handler after each page
+func (c *Crawler) CrawlWithCallback(ctx context.Context, handler module.JobHandler) error {
+	totalJobCount := 0
+	pageErrors := fetch.NewPageErrors(0)
+
+	for page := 1; page <= c.config.MaxPages; page++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		log.Printf("[TopCV] Fetching page %d/%d", page, c.config.MaxPages)
+
+		links, err := c.extractor.ExtractList(ctx, ListingURL, page)
+		if err != nil {
+			// Skip a page that kept failing after retries; give up on fatal errors
+			if err := pageErrors.Fail("[TopCV]", page, err); err != nil {
+				log.Printf("[TopCV] Stopping: %v", err)
+				return err
+			}
+			continue
+		}
+		pageErrors.OK()
+
+		if len(links) == 0 {
+			log.Printf("[TopCV] No more jobs on page %d", page)
+			break
+		}
+
+		jobs := make([]*domain.RawJob, 0, len(links))
+		tracked := 0
+		for _, link := range links {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			jobURL := cleanJobURL(link.URL)
+			if jobURL == "" {
+				continue
+			}
+			if c.tracked(ctx, extractJobID(jobURL)) {
+				tracked++
+				continue
+			}
+
+			job, err := c.FetchDetail(ctx, jobURL)
+			if err != nil {
+				log.Printf("[TopCV] Error extracting %s: %v", jobURL, err)
+			} else {
+				jobs = append(jobs, job)
+			}
+
+			// Random delay: base delay + random 0-2000ms
+			randomDelay := c.config.RequestDelay + time.Duration(rand.Intn(2000))*time.Millisecond
+			if err := fetch.Sleep(ctx, randomDelay); err != nil {
+				return err
+			}
+		}
+		if tracked > 0 {
+			log.Printf("[TopCV] Page %d: skipped %d already tracked jobs", page, tracked)
+		}
+
+		// Process jobs immediately via callback
+		if len(jobs) > 0 {
+			if err := handler(jobs); err != nil {
+				if errors.Is(err, module.ErrStopCrawl) {
+					log.Printf("[TopCV] Early stop after page %d (unchanged pages)", page)
+					break
+				}
+				return fmt.Errorf("handle page %d: %w", page, err)
+			}
+		}
+
+		totalJobCount += len(jobs)
+		log.Printf("[TopCV] Page %d: %d/%d jobs processed", page, len(jobs), len(links))
+	}
+
+	log.Printf("[TopCV] Crawled %d jobs total", totalJobCount)
+	return nil
+}
+
+// tracked reports whether a listed job has already been published
+func (c *Crawler) tracked(ctx context.Context, jobID string) bool {
+	if c.dedup == nil {
+		return false
+	}
+	seen, err := c.dedup.IsSeen(ctx, string(domain.SourceTopCV), jobID)
+	if err != nil {
+		log.Printf("[TopCV] Dedup check error for %s: %v", jobID, err)
+		return false
+	}
+	return seen
+}
+
+// FetchDetail extracts a job detail page and converts its __NEXT_DATA__ payload to a RawJob
+// Also used by sitemap discovery
+func (c *Crawler) FetchDetail(ctx context.Context, jobURL string) (*domain.RawJob, error) {
+	jobURL = cleanJobURL(jobURL)
+	if jobURL == "" {
+		return nil, fmt.Errorf("invalid job url")
+	}
+
+	extracted, err := c.extractor.Extract(ctx, jobURL)
+	if err != nil {
+		return nil, err
+	}
+
+	script, _ := extracted.RawData["__NEXT_DATA__"].(string)
+	if script == "" {
+		return nil, fmt.Errorf("no __NEXT_DATA__ found")
+	}
+
+	var nextData NextData
+	if err := json.Unmarshal([]byte(script), &nextData); err != nil {
+		return nil, fmt.Errorf("parse __NEXT_DATA__: %w", err)
+	}
+
+	item := nextData.Props.PageProps.Job
+	if item.ID == 0 && item.Title == "" {
+		return nil, fmt.Errorf("no job in __NEXT_DATA__")
+	}
+
+	return c.itemToRawJob(item, jobURL), nil
+}
+
+// itemToRawJob converts a __NEXT_DATA__ job to domain.RawJob
+func (c *Crawler) itemToRawJob(item JobDetail, jobURL string) *domain.RawJob {
+	jobID := fmt.Sprintf("%d", item.ID)
+	if item.ID == 0 {
+		jobID = extractJobID(jobURL)
+	}
+
+	// Parse expiry time for TTL
+	expiredOn := dateutil.Parse(item.Deadline)
+	if expiredOn.IsZero() {
+		expiredOn = time.Now().Add(30 * 24 * time.Hour) // Default 30 days
+	}
+
+	// Change detection uses updated_at, falling back to created_at
+	lastUpdatedOn := item.UpdatedAt
+	if lastUpdatedOn == "" {
+		lastUpdatedOn = item.CreatedAt
+	}
+
+	// Extract locations
+	var locations, cities, districts []string
+	for _, addr := range item.Addresses {
+		parts := []string{}
+		if addr.Detail != "" {
+			parts = append(parts, addr.Detail)
+		}
+		if addr.District != "" {
+			parts = append(parts, addr.District)
+			districts = append(districts, addr.District)
+		}
+		if addr.Province != "" {
+			parts = append(parts, addr.Province)
+			cities = append(cities, addr.Province)
+		}
+		if len(parts) > 0 {
+			locations = append(locations, strings.Join(parts, ", "))
+		}
+	}
+
+	var industries []string
+	for _, cat := range item.Categories {
+		if cat.Name != "" {
+			industries = append(industries, cat.Name)
+		}
+	}
+
+	var skills []string
+	for _, s := range item.Skills {
+		if s.Name != "" {
+			skills = append(skills, s.Name)
+		}
+	}
+
+	return &domain.RawJob{
+		ID:            jobID,
+		URL:           jobURL,
+		Source:        string(domain.SourceTopCV),
+		LastUpdatedOn: lastUpdatedOn, // For change detection
+		ExpiredOn:     expiredOn,     // For TTL calculation
+		RawData: map[string]any{
+			// Basic info
+			"title":           item.Title,
+			"company":         item.Company.Name,
+			"company_logo":    item.Company.Logo,
+			"company_website": item.Company.Website,
+			// Location
+			"locations":         locations,
+			"location_city":     cities,
+			"location_district": districts,
+			// Salary
+			"salary_min":        item.Salary.Min,
+			"salary_max":        item.Salary.Max,
+			"salary_text":       item.Salary.Text,
+			"currency":          item.Salary.Currency,
+			"salary_negotiable": item.Salary.Negotiable,
+			// Content
+			"description": item.Description,
+			"requirement": item.Requirement,
+			"benefits":    item.Benefit,
+			// Classification
+			"experience":   item.Experience,
+			"level":        item.Level,
+			"working_form": item.WorkingForm,
+			"quantity":     item.Quantity,
+			"industry":     industries,
+			"skills":       skills,
+			// Stats
+			"total_views":   item.TotalViews,
+			"total_applied": item.TotalApplied,
+			// Dates
+			"created_at": item.CreatedAt,
+			"updated_at": item.UpdatedAt,
+			"deadline":   item.Deadline,
+		},
+		ExtractedAt: time.Now(),
+	}
+}
+
+// cleanJobURL makes the job URL absolute and strips tracking query params
+func cleanJobURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	if u.Host == "" {
+		base, _ := url.Parse(BaseURL)
+		u = base.ResolveReference(u)
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+// extractJobID extracts job ID from URL
+// URL format: https://www.topcv.vn/viec-lam/job-title/1234567.html
+func extractJobID(jobURL string) string {
+	matches := jobIDPattern.FindStringSubmatch(jobURL)
+	if len(matches) > 1 {
+		return matches[1]
+	}
+	return jobURL
+}
+
+// Source returns the source identifier
+func (c *Crawler) Source() domain.JobSource {
+	return domain.SourceTopCV
+}
@@ -0,0 +1,58 @@
+package topcv
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/common/extractor"
+	sitemapfetch "github.com/project-tktt/go-crawler/internal/common/sitemap"
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/module"
+	"github.com/project-tktt/go-crawler/internal/module/sitemap"
+)
+
+func init() {
+	module.Register(module.Registration{
+		Source:   domain.SourceTopCV,
+		Schedule: "0 */6 * * *", // Every 6 hours (detail pages are slow)
+		Factory: func(deps module.Deps) (module.Crawler, error) {
+			deps.LimitHosts(domain.SourceTopCV, "www.topcv.vn")
+			ext := NewDefaultExtractor(extractor.ExtractorConfig{
+				UserAgent: deps.Config.Crawler.UserAgent,
+				HTTP:      deps.HTTP(domain.SourceTopCV),
+			})
+			return NewCrawler(ext, Config{
+				MaxPages:     50,
+				RequestDelay: deps.Config.Crawler.RequestDelay,
+			}).WithDedup(deps.Dedup), nil
+		},
+	})
+
+	// Sitemap discovery runs next to the listing crawler and only fetches
+	// job pages whose <lastmod> changed
+	module.Register(module.Registration{
+		Source:   sitemap.SourceName(domain.SourceTopCV),
+		Site:     domain.SourceTopCV,
+		Schedule: "30 */6 * * *", // Every 6 hours, offset from the listing crawl
+		Factory: func(deps module.Deps) (module.Crawler, error) {
+			deps.LimitHosts(domain.SourceTopCV, "www.topcv.vn")
+			ext := NewDefaultExtractor(extractor.ExtractorConfig{
+				UserAgent: deps.Config.Crawler.UserAgent,
+				HTTP:      deps.HTTP(domain.SourceTopCV),
+			})
+			listing := NewCrawler(ext, Config{RequestDelay: deps.Config.Crawler.RequestDelay})
+			return sitemap.NewCrawler(sitemap.Config{
+				Source:  domain.SourceTopCV,
+				SiteURL: BaseURL,
+				Sitemap: sitemapfetch.Config{
+					UserAgent:     deps.Config.Crawler.UserAgent,
+					HTTP:          deps.HTTP(domain.SourceTopCV),
+					SitemapFilter: regexp.MustCompile(`(?i)job|viec-lam`),
+				},
+				JobURLPattern: regexp.MustCompile(`topcv\.vn/viec-lam/[^/]+/(\d+)\.html`),
+				MaxAge:        7 * 24 * time.Hour,
+				RequestDelay:  deps.Config.Crawler.RequestDelay,
+			}, deps.Dedup, listing.FetchDetail), nil
+		},
+	})
+}
@@ -0,0 +1,81 @@
+package topcv
+
+import "time"
+
+// Config holds TopCV-specific configuration
+type Config struct {
+	MaxPages     int
+	RequestDelay time.Duration
+}
+
+// ==================== __NEXT_DATA__ Types (from detail page) ====================
+
+// NextData represents the Next.js __NEXT_DATA__ payload embedded in job pages
+type NextData struct {
+	Props struct {
+		PageProps struct {
+			Job JobDetail `json:"job"`
+		} `json:"pageProps"`
+	} `json:"props"`
+}
+
+// JobDetail represents a job from the __NEXT_DATA__ payload
+type JobDetail struct {
+	ID           int        `json:"id"`
+	Title        string     `json:"title"`
+	Slug         string     `json:"slug"`
+	URL          string     `json:"url"`
+	Company      Company    `json:"company"`
+	Salary       Salary     `json:"salary"`
+	Addresses    []Address  `json:"addresses"`
+	Experience   string     `json:"experience"`
+	Level        string     `json:"level"`
+	WorkingForm  string     `json:"working_form"`
+	Quantity     int        `json:"quantity"`
+	Categories   []Category `json:"categories"`
+	Skills       []Skill    `json:"skills"`
+	Description  string     `json:"description"`
+	Requirement  string     `json:"requirement"`
+	Benefit      string     `json:"benefit"`
+	Deadline     string     `json:"deadline"`
+	CreatedAt    string     `json:"created_at"`
+	UpdatedAt    string     `json:"updated_at"`
+	TotalViews   int        `json:"total_views"`
+	TotalApplied int        `json:"total_applied"`
+}
+
+// Company holds company information
+type Company struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Logo    string `json:"logo"`
+	Website string `json:"website"`
+}
+
+// Salary holds salary range and display text
+type Salary struct {
+	Min        int    `json:"min"`
+	Max        int    `json:"max"`
+	Currency   string `json:"currency"`
+	Text       string `json:"text"`
+	Negotiable bool   `json:"negotiable"`
+}
+
+// Address represents a job location
+type Address struct {
+	Province string `json:"province"`
+	District string `json:"district"`
+	Detail   string `json:"detail"`
+}
+
+// Category represents an industry category
+type Category struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Skill represents a job skill
+type Skill struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
@@ -3,6 +3,7 @@ package topdev
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -11,6 +12,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/project-tktt/go-crawler/internal/common/dateutil"
+	"github.com/project-tktt/go-crawler/internal/common/dedup"
+	"github.com/project-tktt/go-crawler/internal/common/fetch"
 	"github.com/project-tktt/go-crawler/internal/domain"
 	"github.com/project-tktt/go-crawler/internal/module"
 )
@@ -18,13 +22,20 @@ import (
 const (
 	// Real API endpoint discovered from browser network analysis
 	SearchAPIURL = "https://api.topdev.vn/td/v2/jobs"
+	APIHost      = "api.topdev.vn"
 	JobsPerPage  = 20
+
+	// listFields is the field set requested from the search endpoint
+	listFields = "id,title,slug,company,salary,skills_str,skills,work_locations,responsibilities_original,requirements_original,benefits_original,published_at,expired_at,years_of_experience,job_level,job_type"
+	// detailFields is the field set requested from the per-job detail endpoint
+	detailFields = listFields + ",contract_type"
 )
 
 // Crawler implements job crawling for TopDev
 type Crawler struct {
 	client *http.Client
 	config Config
+	dedup  *dedup.Deduplicator // Skips detail fetches of unchanged jobs (nil = fetch all)
 }
 
 // Config holds TopDev-specific configuration
@@ -32,6 +43,8 @@ type Config struct {
 	MaxPages     int
 	RequestDelay time.Duration
 	UserAgent    string
+	SkipDetails  bool          // Skip the per-job detail fetch (search fields only)
+	HTTP         fetch.Options // Shared rate limit, retry and proxy layers
 }
 
 // SearchResponse is the TopDev API response
@@ -45,6 +58,11 @@ type SearchResponse struct {
 	} `json:"meta"`
 }
 
+// DetailResponse is the TopDev job detail API response
+type DetailResponse struct {
+	Data JobData `json:"data"`
+}
+
 type JobData struct {
 	ID                       int         `json:"id"`
 	Slug                     string      `json:"slug"`
@@ -58,11 +76,13 @@ type JobData struct {
 	ResponsibilitiesOriginal string      `json:"responsibilities_original"`
 	RequirementsOriginal     string      `json:"requirements_original"`
 	BenefitsOriginal         []Benefit   `json:"benefits_original"`
-	PublishedAt              string      `json:"published_at"`
-	ExpiredAt                string      `json:"expired_at"`
+	PublishedAt              Timestamp   `json:"published_at"`
+	ExpiredAt                Timestamp   `json:"expired_at"`
 	IsSalaryVisible          bool        `json:"is_salary_visible"`
 	YearsOfExperience        interface{} `json:"years_of_experience"`
 	JobLevel                 interface{} `json:"job_level"`
+	JobType                  interface{} `json:"job_type"`
+	ContractType             interface{} `json:"contract_type"`
 }
 
 type Company struct {
@@ -96,6 +116,34 @@ type Benefit struct {
 	Value string `json:"value"`
 }
 
+// Timestamp holds a TopDev date, which the API returns either as a plain
+// string or as an object like {"date": "...", "datetime": "...", "since": "..."}
+type Timestamp string
+
+// UnmarshalJSON accepts both the string and the object form
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = Timestamp(s)
+		return nil
+	}
+
+	var obj struct {
+		Date     string `json:"date"`
+		Datetime string `json:"datetime"`
+	}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		*t = "" // null or unexpected shape
+		return nil
+	}
+	if obj.Datetime != "" {
+		*t = Timestamp(obj.Datetime)
+	} else {
+		*t = Timestamp(obj.Date)
+	}
+	return nil
+}
+
 // NewCrawler creates a new TopDev crawler
 func NewCrawler(cfg Config) *Crawler {
 	if cfg.MaxPages <= 0 {
@@ -109,11 +157,18 @@ func NewCrawler(cfg Config) *Crawler {
 	}
 
 	return &Crawler{
-		client: &http.Client{Timeout: 30 * time.Second},
+		client: &http.Client{Timeout: 30 * time.Second, Transport: cfg.HTTP.Transport(nil)},
 		config: cfg,
 	}
 }
 
+// WithDedup fetches details only for jobs that are new or changed according
+// to the runner's dedup markers
+func (c *Crawler) WithDedup(d *dedup.Deduplicator) *Crawler {
+	c.dedup = d
+	return c
+}
+
 // Crawl fetches job listings from TopDev API
 func (c *Crawler) Crawl(ctx context.Context) ([]*domain.RawJob, error) {
 	var allJobs []*domain.RawJob
@@ -127,6 +182,7 @@ func (c *Crawler) Crawl(ctx context.Context) ([]*domain.RawJob, error) {
 // CrawlWithCallback fetches jobs page by page and calls handler after each page
 func (c *Crawler) CrawlWithCallback(ctx context.Context, handler module.JobHandler) error {
 	totalJobCount := 0
+	pageErrors := fetch.NewPageErrors(0)
 
 	for page := 1; page <= c.config.MaxPages; page++ {
 		select {
@@ -137,20 +193,59 @@ func (c *Crawler) CrawlWithCallback(ctx context.Context, handler module.JobHandl
 
 		log.Printf("[TopDev] Fetching page %d/%d", page, c.config.MaxPages)
 
-		jobs, totalPages, err := c.fetchPage(ctx, page)
+		items, totalPages, err := c.fetchPage(ctx, page)
 		if err != nil {
-			log.Printf("[TopDev] Error on page %d: %v", page, err)
-			break
+			// Skip a page that kept failing after retries; give up on fatal errors
+			if err := pageErrors.Fail("[TopDev]", page, err); err != nil {
+				log.Printf("[TopDev] Stopping: %v", err)
+				return err
+			}
+			continue
 		}
+		pageErrors.OK()
 
-		if len(jobs) == 0 {
+		if len(items) == 0 {
 			log.Printf("[TopDev] No more jobs on page %d", page)
 			break
 		}
 
+		jobs := make([]*domain.RawJob, 0, len(items))
+		details := 0
+		for _, item := range items {
+			job := itemToRawJob(item)
+			if !c.config.SkipDetails && c.changed(ctx, job) {
+				detail, err := c.fetchDetail(ctx, item.ID)
+				if err != nil {
+					// Fall back to the search fields with an empty marker, so the
+					// job is not marked seen as current and the next run retries
+					// the detail
+					log.Printf("[TopDev] Detail error for %d: %v", item.ID, err)
+					job.LastUpdatedOn = ""
+				} else {
+					merged := itemToRawJob(mergeDetail(item, detail))
+					// Keep the search-result marker, so the next run compares like with like
+					merged.LastUpdatedOn = job.LastUpdatedOn
+					job = merged
+				}
+				details++
+
+				// Random delay: base delay + random 0-2000ms
+				randomDelay := c.config.RequestDelay + time.Duration(rand.Intn(2000))*time.Millisecond
+				time.Sleep(randomDelay)
+			}
+			jobs = append(jobs, job)
+		}
+		if !c.config.SkipDetails {
+			log.Printf("[TopDev] Page %d: fetched %d/%d details (others unchanged)", page, details, len(items))
+		}
+
 		// Process jobs immediately via callback
 		if err := handler(jobs); err != nil {
-			log.Printf("[TopDev] Handler error on page %d: %v", page, err)
+			if errors.Is(err, module.ErrStopCrawl) {
+				log.Printf("[TopDev] Early stop after page %d (unchanged pages)", page)
+				break
+			}
+			return fmt.Errorf("handle page %d: %w", page, err)
 		}
 
 		totalJobCount += len(jobs)
@@ -171,14 +266,53 @@ func (c *Crawler) CrawlWithCallback(ctx context.Context, handler module.JobHandl
 	return nil
 }
 
+// changed reports whether a search result is new or updated since it was
+// last published; the runner makes the same check before publishing
+func (c *Crawler) changed(ctx context.Context, job *domain.RawJob) bool {
+	if c.dedup == nil {
+		return true
+	}
+	result, err := c.dedup.CheckJob(ctx, job.Source, job.ID, job.LastUpdatedOn)
+	if err != nil {
+		log.Printf("[TopDev] Dedup check error for %s: %v", job.ID, err)
+		return true
+	}
+	return result != dedup.ResultUnchanged
+}
+
 // fetchPage fetches a single page of jobs
-func (c *Crawler) fetchPage(ctx context.Context, page int) ([]*domain.RawJob, int, error) {
+func (c *Crawler) fetchPage(ctx context.Context, page int) ([]JobData, int, error) {
 	// Use fields[job] parameter to get full job details
-	url := fmt.Sprintf("%s?page=%d&limit=%d&locale=vi_VN&fields[job]=id,title,slug,company,salary,skills_str,work_locations,responsibilities_original,requirements_original,benefits_original", SearchAPIURL, page, JobsPerPage)
+	url := fmt.Sprintf("%s?page=%d&limit=%d&locale=vi_VN&fields[job]=%s", SearchAPIURL, page, JobsPerPage, listFields)
+
+	var searchResp SearchResponse
+	if err := c.getJSON(ctx, url, &searchResp); err != nil {
+		return nil, 0, err
+	}
 
+	return searchResp.Data, searchResp.Meta.LastPage, nil
+}
+
+// fetchDetail fetches the full record of a single job
+func (c *Crawler) fetchDetail(ctx context.Context, jobID int) (JobData, error) {
+	url := fmt.Sprintf("%s/%d?locale=vi_VN&fields[job]=%s", SearchAPIURL, jobID, detailFields)
+
+	var detailResp DetailResponse
+	if err := c.getJSON(ctx, url, &detailResp); err != nil {
+		return JobData{}, err
+	}
+	if detailResp.Data.ID == 0 {
+		return JobData{}, fmt.Errorf("empty detail response")
+	}
+
+	return detailResp.Data, nil
+}
+
+// getJSON performs a GET request and decodes the JSON response into out
+func (c *Crawler) getJSON(ctx context.Context, url string, out any) error {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, 0, fmt.Errorf("create request: %w", err)
+		return fmt.Errorf("create request: %w", err)
 	}
 
 	req.Header.Set("Accept", "application/json")
@@ -187,100 +321,190 @@ func (c *Crawler) fetchPage(ctx context.Context, page int) ([]*domain.RawJob, in
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, 0, fmt.Errorf("do request: %w", err)
+		return fmt.Errorf("do request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, 0, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	if err := fetch.CheckResponse(resp); err != nil {
+		return err
 	}
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, 0, fmt.Errorf("read body: %w", err)
+		return fmt.Errorf("read body: %w", err)
 	}
 
-	var searchResp SearchResponse
-	if err := json.Unmarshal(respBody, &searchResp); err != nil {
-		return nil, 0, fmt.Errorf("parse response: %w", err)
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("parse response: %w", err)
 	}
 
-	jobs := make([]*domain.RawJob, 0, len(searchResp.Data))
-	for _, item := range searchResp.Data {
-		// Build job URL
-		jobURL := fmt.Sprintf("https://topdev.vn/job/%s", item.Slug)
-		if item.Slug == "" {
-			jobURL = fmt.Sprintf("https://topdev.vn/job/%d", item.ID)
-		}
+	return nil
+}
 
-		// Extract skill names - skills_str is a comma-separated string
-		var skillNames []string
-		if item.SkillsStr != "" {
-			// Split comma-separated skills
-			for _, s := range strings.Split(item.SkillsStr, ",") {
-				if trimmed := strings.TrimSpace(s); trimmed != "" {
-					skillNames = append(skillNames, trimmed)
-				}
-			}
-		} else {
-			for _, s := range item.Skills {
-				skillNames = append(skillNames, s.Name)
+// mergeDetail overlays non-empty detail fields onto the search item
+func mergeDetail(item, detail JobData) JobData {
+	if detail.Title != "" {
+		item.Title = detail.Title
+	}
+	if detail.Company.DisplayName != "" {
+		item.Company = detail.Company
+	}
+	if detail.Salary.Value != "" || detail.Salary.MinFilter > 0 || detail.Salary.MaxFilter > 0 {
+		item.Salary = detail.Salary
+	}
+	if detail.SkillsStr != "" {
+		item.SkillsStr = detail.SkillsStr
+	}
+	if len(detail.Skills) > 0 {
+		item.Skills = detail.Skills
+	}
+	if len(detail.WorkLocations) > 0 {
+		item.WorkLocations = detail.WorkLocations
+	}
+	if detail.ResponsibilitiesOriginal != "" {
+		item.ResponsibilitiesOriginal = detail.ResponsibilitiesOriginal
+	}
+	if detail.RequirementsOriginal != "" {
+		item.RequirementsOriginal = detail.RequirementsOriginal
+	}
+	if len(detail.BenefitsOriginal) > 0 {
+		item.BenefitsOriginal = detail.BenefitsOriginal
+	}
+	if detail.PublishedAt != "" {
+		item.PublishedAt = detail.PublishedAt
+	}
+	if detail.ExpiredAt != "" {
+		item.ExpiredAt = detail.ExpiredAt
+	}
+	if detail.YearsOfExperience != nil {
+		item.YearsOfExperience = detail.YearsOfExperience
+	}
+	if detail.JobLevel != nil {
+		item.JobLevel = detail.JobLevel
+	}
+	if detail.JobType != nil {
+		item.JobType = detail.JobType
+	}
+	if detail.ContractType != nil {
+		item.ContractType = detail.ContractType
+	}
+	return item
+}
+
+// itemToRawJob converts an API job to domain.RawJob
+func itemToRawJob(item JobData) *domain.RawJob {
+	// Build job URL
+	jobURL := fmt.Sprintf("https://topdev.vn/job/%s", item.Slug)
+	if item.Slug == "" {
+		jobURL = fmt.Sprintf("https://topdev.vn/job/%d", item.ID)
+	}
+
+	// Extract skill names - skills_str is a comma-separated string
+	var skillNames []string
+	if item.SkillsStr != "" {
+		// Split comma-separated skills
+		for _, s := range strings.Split(item.SkillsStr, ",") {
+			if trimmed := strings.TrimSpace(s); trimmed != "" {
+				skillNames = append(skillNames, trimmed)
 			}
 		}
+	} else {
+		for _, s := range item.Skills {
+			skillNames = append(skillNames, s.Name)
+		}
+	}
 
-		// Extract locations
-		var locations []string
-		for _, loc := range item.WorkLocations {
-			parts := []string{}
-			if loc.Address != "" {
-				parts = append(parts, loc.Address)
-			}
-			if loc.District != "" {
-				parts = append(parts, loc.District)
-			}
-			if loc.City != "" {
-				parts = append(parts, loc.City)
-			}
-			if len(parts) > 0 {
-				locations = append(locations, strings.Join(parts, ", "))
-			}
+	// Keep full skill objects (id + name)
+	skillObjects := make([]map[string]any, 0, len(item.Skills))
+	for _, s := range item.Skills {
+		skillObjects = append(skillObjects, map[string]any{"id": s.ID, "name": s.Name})
+	}
+
+	// Extract locations
+	var locations []string
+	for _, loc := range item.WorkLocations {
+		parts := []string{}
+		if loc.Address != "" {
+			parts = append(parts, loc.Address)
+		}
+		if loc.District != "" {
+			parts = append(parts, loc.District)
+		}
+		if loc.City != "" {
+			parts = append(parts, loc.City)
 		}
+		if len(parts) > 0 {
+			locations = append(locations, strings.Join(parts, ", "))
+		}
+	}
 
-		// Extract benefits
-		var benefits []string
-		for _, b := range item.BenefitsOriginal {
-			if b.Value != "" {
-				benefits = append(benefits, b.Value)
-			}
+	// Extract benefits
+	var benefits []string
+	for _, b := range item.BenefitsOriginal {
+		if b.Value != "" {
+			benefits = append(benefits, b.Value)
 		}
+	}
+
+	// Parse expiredAt for TTL
+	expiredOn := dateutil.Parse(string(item.ExpiredAt))
+	if expiredOn.IsZero() {
+		expiredOn = time.Now().Add(30 * 24 * time.Hour) // Default 30 days
+	}
 
-		jobs = append(jobs, &domain.RawJob{
-			ID:     fmt.Sprintf("%d", item.ID),
-			URL:    jobURL,
-			Source: string(domain.SourceTopDev),
-			RawData: map[string]any{
-				"title":        item.Title,
-				"company":      item.Company.DisplayName,
-				"company_logo": item.Company.ImageLogo,
-				"salary_min":   item.Salary.MinFilter,
-				"salary_max":   item.Salary.MaxFilter,
-				"salary_text":  item.Salary.Value,
-				"currency":     item.Salary.Currency,
-				"skills":       skillNames,
-				"locations":    locations,
-				"description":  item.ResponsibilitiesOriginal,
-				"requirement":  item.RequirementsOriginal,
-				"benefits":     benefits,
-				"published_at": item.PublishedAt,
-				"expired_at":   item.ExpiredAt,
-				"experience":   item.YearsOfExperience,
-				"level":        item.JobLevel,
-			},
-			ExtractedAt: time.Now(),
-		})
-	}
-
-	return jobs, searchResp.Meta.LastPage, nil
+	return &domain.RawJob{
+		ID:            fmt.Sprintf("%d", item.ID),
+		URL:           jobURL,
+		Source:        string(domain.SourceTopDev),
+		LastUpdatedOn: updateMarker(item, skillNames, locations, benefits), // For change detection
+		ExpiredOn:     expiredOn,                                           // For TTL calculation
+		RawData: map[string]any{
+			"title":         item.Title,
+			"company":       item.Company.DisplayName,
+			"company_logo":  item.Company.ImageLogo,
+			"salary_min":    item.Salary.MinFilter,
+			"salary_max":    item.Salary.MaxFilter,
+			"salary_text":   item.Salary.Value,
+			"currency":      item.Salary.Currency,
+			"skills":        skillNames,
+			"skill_objects": skillObjects,
+			"locations":     locations,
+			"description":   item.ResponsibilitiesOriginal,
+			"requirement":   item.RequirementsOriginal,
+			"benefits":      benefits,
+			"published_at":  string(item.PublishedAt),
+			"expired_at":    string(item.ExpiredAt),
+			"experience":    item.YearsOfExperience,
+			"level":         item.JobLevel,
+			"job_type":      item.JobType,
+			"contract_type": item.ContractType,
+		},
+		ExtractedAt: time.Now(),
+	}
+}
+
+// updateMarker builds a stable change-detection value for a job from its
+// search-result fields
+// TopDev has no updated_at field, so published_at is combined with a fingerprint
+// of the content fields; edits to a job change the fingerprint even when
+// published_at stays the same
+func updateMarker(item JobData, skills, locations, benefits []string) string {
+	fingerprint := dedup.Fingerprint(
+		item.Title,
+		item.Company.DisplayName,
+		item.Salary.Value,
+		fmt.Sprintf("%d-%d", item.Salary.MinFilter, item.Salary.MaxFilter),
+		item.ResponsibilitiesOriginal,
+		item.RequirementsOriginal,
+		strings.Join(skills, ","),
+		strings.Join(locations, ";"),
+		strings.Join(benefits, ";"),
+		string(item.ExpiredAt),
+	)
+	if item.PublishedAt == "" {
+		return fingerprint
+	}
+	return string(item.PublishedAt) + "|" + fingerprint
 }
 
 // Source returns the source identifier
@@ -0,0 +1,22 @@
+package topdev
+
+import (
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/module"
+)
+
+func init() {
+	module.Register(module.Registration{
+		Source:   domain.SourceTopDev,
+		Schedule: "0 * * * *", // Every hour
+		Factory: func(deps module.Deps) (module.Crawler, error) {
+			deps.LimitHosts(domain.SourceTopDev, APIHost)
+			return NewCrawler(Config{
+				MaxPages:     500,
+				RequestDelay: deps.Config.Crawler.RequestDelay,
+				UserAgent:    deps.Config.Crawler.UserAgent,
+				HTTP:         deps.HTTP(domain.SourceTopDev),
+			}).WithDedup(deps.Dedup), nil
+		},
+	})
+}
@@ -3,6 +3,7 @@ package vieclam24h
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -10,7 +11,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/project-tktt/go-crawler/internal/common/breaker"
 	"github.com/project-tktt/go-crawler/internal/common/dedup"
+	"github.com/project-tktt/go-crawler/internal/common/fetch"
 	"github.com/project-tktt/go-crawler/internal/domain"
 	"github.com/project-tktt/go-crawler/internal/module"
 	"github.com/project-tktt/go-crawler/internal/queue"
@@ -19,6 +22,8 @@ import (
 const (
 	BaseURL   = "https://vieclam24h.vn"
 	SearchAPI = "https://apiv2.vieclam24h.vn/employer/fe/job/get-job-list"
+	APIHost   = "apiv2.vieclam24h.vn"
+	WebHost   = "vieclam24h.vn"
 )
 
 // Crawler implements job crawling for Vieclam24h using API
@@ -26,11 +31,16 @@ type Crawler struct {
 	client       *http.Client
 	config       Config
 	dedup        *dedup.Deduplicator
-	pendingQueue *queue.Publisher // Queue for jobs needing detail scrape
+	pendingQueue queue.Publisher // Queue for jobs needing detail scrape
+	tokens       TokenProvider
+	incremental  *module.Incremental
+	stopReason   module.StopReason // Why the last run ended
+	startPage    int               // 1-based page to start from (set by Resume)
+	position     module.Position
 }
 
 // NewCrawler creates a new Vieclam24h crawler
-func NewCrawler(cfg Config, deduplicator *dedup.Deduplicator, pendingQueue *queue.Publisher) *Crawler {
+func NewCrawler(cfg Config, deduplicator *dedup.Deduplicator, pendingQueue queue.Publisher) *Crawler {
 	if cfg.MaxPages <= 0 {
 		cfg.MaxPages = 50 // Unlimited, rely on API's LastPage
 	}
@@ -48,13 +58,28 @@ func NewCrawler(cfg Config, deduplicator *dedup.Deduplicator, pendingQueue *queu
 	}
 
 	return &Crawler{
-		client:       &http.Client{Timeout: 30 * time.Second},
+		client:       &http.Client{Timeout: 30 * time.Second, Transport: cfg.HTTP.Transport(nil)},
 		config:       cfg,
 		dedup:        deduplicator,
 		pendingQueue: pendingQueue,
+		tokens:       StaticToken(cfg.BearerToken),
 	}
 }
 
+// WithTokens acquires and refreshes the API bearer token through a provider
+// instead of the fixed Config.BearerToken
+func (c *Crawler) WithTokens(tokens TokenProvider) *Crawler {
+	c.tokens = tokens
+	return c
+}
+
+// WithIncremental enables early stop after fully unchanged pages
+// The job list API returns newest updates first
+func (c *Crawler) WithIncremental(inc *module.Incremental) *Crawler {
+	c.incremental = inc
+	return c
+}
+
 // Crawl fetches job listings from Vieclam24h API
 func (c *Crawler) Crawl(ctx context.Context) ([]*domain.RawJob, error) {
 	var allJobs []*domain.RawJob
@@ -69,18 +94,42 @@ func (c *Crawler) Crawl(ctx context.Context) ([]*domain.RawJob, error) {
 func (c *Crawler) CrawlWithCallback(ctx context.Context, handler module.JobHandler) error {
 	totalJobCount := 0
 	newJobCount := 0
+	c.stopReason = module.StopMaxPages
+	c.incremental.Begin(ctx)
+	pageErrors := fetch.NewPageErrors(0)
+
+	startPage := max(c.startPage, 1)
+	c.startPage = 0 // Resume applies to one run only
+	if startPage > 1 {
+		log.Printf("[Vieclam24h] Resuming from page %d", startPage)
+	}
+
+	for page := startPage; page <= c.config.MaxPages; page++ {
+		if ctx.Err() != nil {
+			c.stopReason = module.StopCancelled
+			break
+		}
 
-	for page := 1; page <= c.config.MaxPages; page++ {
 		log.Printf("[Vieclam24h] Fetching page %d", page)
 
 		resp, err := c.fetchPage(ctx, page)
 		if err != nil {
-			log.Printf("[Vieclam24h] Error on page %d: %v", page, err)
-			break
+			// Skip a page that kept failing after retries; give up on fatal errors
+			if err := pageErrors.Fail("[Vieclam24h]", page, err); err != nil {
+				log.Printf("[Vieclam24h] Stopping: %v", err)
+				c.stopReason = module.StopError
+				if errors.Is(err, breaker.ErrOpen) || errors.Is(err, breaker.ErrBanned) {
+					c.stopReason = module.StopBlocked
+				}
+				break
+			}
+			continue
 		}
+		pageErrors.OK()
 
 		if len(resp.Data.Items) == 0 {
 			log.Printf("[Vieclam24h] No more jobs on page %d", page)
+			c.stopReason = module.StopCompleted
 			break
 		}
 
@@ -144,8 +193,10 @@ func (c *Crawler) CrawlWithCallback(ctx context.Context, handler module.JobHandl
 		newJobCount += len(pendingJobs)
 		totalJobCount += len(resp.Data.Items)
 
-		// Call handler if provided
-		if handler != nil && len(pendingJobs) > 0 {
+		// Call handler for every page (even with no pending jobs) so the
+		// runner can checkpoint the position
+		c.position = module.Position{Page: page}
+		if handler != nil {
 			if err := handler(pendingJobs); err != nil {
 				log.Printf("[Vieclam24h] Handler error on page %d: %v", page, err)
 			}
@@ -154,15 +205,24 @@ func (c *Crawler) CrawlWithCallback(ctx context.Context, handler module.JobHandl
 		log.Printf("[Vieclam24h] Page %d summary: %d total | %d NEW | %d UPDATED | %d UNCHANGED",
 			page, len(resp.Data.Items), newCount, updatedCount, unchangedCount)
 
+		// Incremental mode: stop after N consecutive fully unchanged pages
+		if c.incremental.Page(len(resp.Data.Items), unchangedCount) {
+			log.Printf("[Vieclam24h] Early stop after page %d (unchanged pages)", page)
+			c.stopReason = module.StopUnchanged
+			break
+		}
+
 		// Stop if we've reached the last page (if LastPage is valid)
 		if resp.Data.Pagination.LastPage > 0 && page >= resp.Data.Pagination.LastPage {
 			log.Printf("[Vieclam24h] Reached last page (%d)", resp.Data.Pagination.LastPage)
+			c.stopReason = module.StopCompleted
 			break
 		}
 
 		// Fallback: Stop if we received fewer items than requested
 		if len(resp.Data.Items) < c.config.PerPage {
 			log.Printf("[Vieclam24h] Page %d has %d items (< %d), stopping", page, len(resp.Data.Items), c.config.PerPage)
+			c.stopReason = module.StopCompleted
 			break
 		}
 
@@ -171,7 +231,9 @@ func (c *Crawler) CrawlWithCallback(ctx context.Context, handler module.JobHandl
 		time.Sleep(randomDelay)
 	}
 
-	log.Printf("[Vieclam24h] Crawled %d jobs total, %d new/updated", totalJobCount, newJobCount)
+	c.incremental.Finish(ctx, c.stopReason)
+
+	log.Printf("[Vieclam24h] Crawled %d jobs total, %d new/updated (stop: %s)", totalJobCount, newJobCount, c.stopReason)
 	return nil
 }
 
@@ -179,23 +241,31 @@ func (c *Crawler) CrawlWithCallback(ctx context.Context, handler module.JobHandl
 func (c *Crawler) fetchPage(ctx context.Context, page int) (*APIResponse, error) {
 	url := fmt.Sprintf("%s?page=%d&per_page=%d&request_from=search_result_web", SearchAPI, page, c.config.PerPage)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	token, err := c.tokens.Token(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, &TokenRefreshError{Err: err}
 	}
 
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.config.BearerToken)
-	req.Header.Set("X-Branch", c.config.Branch)
-
-	resp, err := c.client.Do(req)
+	resp, err := c.doRequest(ctx, url, token)
 	if err != nil {
-		return nil, fmt.Errorf("do request: %w", err)
+		return nil, err
+	}
+
+	// A rejected token is refreshed once and the request retried
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		resp.Body.Close()
+		log.Printf("[Vieclam24h] API rejected bearer token (status %d), refreshing", resp.StatusCode)
+		if token, err = c.tokens.Refresh(ctx, token); err != nil {
+			return nil, err
+		}
+		if resp, err = c.doRequest(ctx, url, token); err != nil {
+			return nil, err
+		}
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	if err := fetch.CheckResponse(resp); err != nil {
+		return nil, err
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -215,6 +285,26 @@ func (c *Crawler) fetchPage(ctx context.Context, page int) (*APIResponse, error)
 	return &apiResp, nil
 }
 
+// doRequest sends an authenticated job list request
+func (c *Crawler) doRequest(ctx context.Context, url, token string) (*http.Response, error) {
+	// fetchPage refreshes rejected tokens; the breaker and proxy layers must
+	// not count them as bans
+	req, err := http.NewRequestWithContext(fetch.WithTokenAuth(ctx), http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Branch", c.config.Branch)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	return resp, nil
+}
+
 // itemToRawJob converts API item to domain.RawJob
 func (c *Crawler) itemToRawJob(item JobItem) *domain.RawJob {
 	jobURL := fmt.Sprintf("%s/%s-c%dp%did%d.html",
@@ -279,6 +369,22 @@ func (c *Crawler) itemToRawJob(item JobItem) *domain.RawJob {
 	}
 }
 
+// Position returns the page currently handed to the handler
+func (c *Crawler) Position() module.Position {
+	return c.position
+}
+
+// Resume makes the next run start after pos.Page
+func (c *Crawler) Resume(pos module.Position) {
+	c.startPage = pos.Page + 1
+	c.position = pos
+}
+
+// StopReason returns why the last run ended
+func (c *Crawler) StopReason() module.StopReason {
+	return c.stopReason
+}
+
 // Source returns the source identifier
 func (c *Crawler) Source() domain.JobSource {
 	return domain.SourceVieclam24h
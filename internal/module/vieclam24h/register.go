@@ -0,0 +1,78 @@
+package vieclam24h
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/common/extractor"
+	sitemapfetch "github.com/project-tktt/go-crawler/internal/common/sitemap"
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/module"
+	"github.com/project-tktt/go-crawler/internal/module/sitemap"
+	"github.com/project-tktt/go-crawler/internal/queue"
+)
+
+// PendingQueue holds jobs waiting for detail scraping by the enricher
+const PendingQueue = "jobs:pending:vieclam24h"
+
+func init() {
+	module.Register(module.Registration{
+		Source:         domain.SourceVieclam24h,
+		Schedule:       "0 */6 * * *", // Every 6 hours at minute 0
+		Queue:          PendingQueue,
+		SelfPublishing: true,
+		Factory: func(deps module.Deps) (module.Crawler, error) {
+			crawlerConfig := DefaultConfig()
+			if deps.Config.Crawler.RequestDelay > 0 {
+				crawlerConfig.RequestDelay = deps.Config.Crawler.RequestDelay
+			}
+			crawlerConfig.VerboseLog = deps.Config.Crawler.VerboseLog
+			crawlerConfig.HTTP = deps.HTTP(domain.SourceVieclam24h)
+			deps.LimitHosts(domain.SourceVieclam24h, APIHost, WebHost)
+
+			tokens := NewTokenProvider(deps.Redis, &http.Client{
+				Timeout:   30 * time.Second,
+				Transport: crawlerConfig.HTTP.Transport(nil),
+			}, TokenConfig{
+				File:     deps.Config.Crawler.TokenFiles[string(domain.SourceVieclam24h)],
+				Fallback: crawlerConfig.BearerToken,
+			})
+
+			return NewCrawler(
+				crawlerConfig,
+				deps.Dedup,
+				queue.NewPublisher(deps.Redis, PendingQueue),
+			).WithIncremental(deps.Incremental(domain.SourceVieclam24h)).WithTokens(tokens), nil
+		},
+	})
+
+	// Sitemap discovery reads detail pages straight from their JSON-LD and
+	// publishes to the raw queue (normalized via the JobPosting layout)
+	module.Register(module.Registration{
+		Source:   sitemap.SourceName(domain.SourceVieclam24h),
+		Site:     domain.SourceVieclam24h,
+		Schedule: "30 */6 * * *", // Every 6 hours, offset from the listing crawl
+		Factory: func(deps module.Deps) (module.Crawler, error) {
+			deps.LimitHosts(domain.SourceVieclam24h, WebHost)
+			ext := extractor.NewCollyExtractor(domain.SourceVieclam24h, extractor.Selectors{
+				JSONLD: true,
+			}, extractor.ExtractorConfig{
+				UserAgent: deps.Config.Crawler.UserAgent,
+				HTTP:      deps.HTTP(domain.SourceVieclam24h),
+			})
+			return sitemap.NewCrawler(sitemap.Config{
+				Source:  domain.SourceVieclam24h,
+				SiteURL: BaseURL,
+				Sitemap: sitemapfetch.Config{
+					UserAgent:     deps.Config.Crawler.UserAgent,
+					HTTP:          deps.HTTP(domain.SourceVieclam24h),
+					SitemapFilter: regexp.MustCompile(`(?i)job|viec-lam|tin-tuyen-dung`),
+				},
+				JobURLPattern: regexp.MustCompile(`vieclam24h\.vn/.+id(\d+)\.html`),
+				MaxAge:        7 * 24 * time.Hour,
+				RequestDelay:  deps.Config.Crawler.RequestDelay,
+			}, deps.Dedup, ext.Extract), nil
+		},
+	})
+}
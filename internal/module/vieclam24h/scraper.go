@@ -3,6 +3,7 @@ package vieclam24h
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -12,68 +13,101 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/project-tktt/go-crawler/internal/common/breaker"
+	"github.com/project-tktt/go-crawler/internal/common/fetch"
+	"github.com/project-tktt/go-crawler/internal/common/httpcache"
 	"github.com/project-tktt/go-crawler/internal/domain"
 	"github.com/project-tktt/go-crawler/internal/queue"
 )
 
+// maxPageSize caps a detail page read (decompressed)
+const maxPageSize = 10 << 20
+
 // Scraper consumes pending jobs and scrapes details
 type Scraper struct {
-	consumer        *queue.Consumer
-	publisher       *queue.Publisher
-	jsonLdPublisher *queue.Publisher // For JSON-LD validation
+	consumer        queue.Consumer
+	publisher       queue.Publisher
+	jsonLdPublisher queue.Publisher // For JSON-LD validation (optional)
 	client          *http.Client
+	transport       *http.Transport  // Base transport under the fetch layers
+	cache           *httpcache.Cache // Conditional-request cache (nil = always full fetch)
+	breaker         *breaker.Breaker // Pauses scraping while the source is blocked
 	requestDelay    time.Duration
 }
 
 // NewScraper creates a new detail scraper
-func NewScraper(consumer *queue.Consumer, publisher *queue.Publisher, jsonLdPublisher *queue.Publisher, delay time.Duration) *Scraper {
+func NewScraper(consumer queue.Consumer, publisher queue.Publisher, jsonLdPublisher queue.Publisher, delay time.Duration) *Scraper {
 	if delay <= 0 {
 		delay = 5*time.Second + time.Duration(rand.Intn(3000))*time.Millisecond
 	}
+	// Compression is negotiated by fetch.Decompress (gzip and brotli)
+	transport := &http.Transport{
+		MaxIdleConns:       10,
+		IdleConnTimeout:    30 * time.Second,
+		DisableCompression: true,
+	}
 	return &Scraper{
 		consumer:        consumer,
 		publisher:       publisher,
 		jsonLdPublisher: jsonLdPublisher,
 		requestDelay:    delay,
+		transport:       transport,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:       10,
-				IdleConnTimeout:    30 * time.Second,
-				DisableCompression: true,
-			},
+			Timeout:   30 * time.Second,
+			Transport: fetch.Decompress(transport),
 		},
 	}
 }
 
+// WithHTTP routes detail page fetches through the shared fetch layers so the
+// enricher and the listing crawler share one rate budget, proxy pool and
+// circuit breaker
+func (s *Scraper) WithHTTP(opts fetch.Options) *Scraper {
+	opts.Decompress = true
+	s.client.Transport = opts.Transport(s.transport)
+	s.breaker = opts.Breaker
+	return s
+}
+
+// WithCache revalidates detail pages with If-None-Match/If-Modified-Since
+// and reuses the cached enrichment when the page is not modified
+func (s *Scraper) WithCache(cache *httpcache.Cache) *Scraper {
+	s.cache = cache
+	return s
+}
+
 // Run starts the scraper loop
 func (s *Scraper) Run(ctx context.Context) error {
 	log.Printf("[Vieclam24h] Starting detail scraper (delay: %v)...", s.requestDelay)
 
-	return s.consumer.Run(ctx, func(job *domain.RawJob) error {
+	return s.consumer.Run(ctx, func(ctx context.Context, job *domain.RawJob) error {
 		log.Printf("[Vieclam24h] Scraping detail for %s (%s)", job.ID, job.URL)
 
-		// Fetch full HTML content
-		htmlContent, err := s.fetchHTML(ctx, job.URL)
+		// Fetch full HTML content (or revalidate the cached copy)
+		enrichment, err := s.enrich(ctx, job)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if s.blocked(ctx, err) {
+			// A banned source must not publish jobs without their detail
+			// fields: the job is rescheduled (dead-lettered on its last
+			// attempt) and consumption pauses until the circuit closes
+			return queue.Pause(fmt.Errorf("fetch detail page: %w", err), s.breaker.Wait)
+		}
 		if err != nil {
-			log.Printf("[Vieclam24h] Failed to fetch HTML for %s: %v", job.ID, err)
-			// Decide whether to retry or push partial data.
-			// For now, we log and proceed with existing API data (which is quite full)
-			// But ideally we might want to retry.
-		} else {
-			// Update job with HTML content (temporary for enrichment)
-			job.HTMLContent = htmlContent
-
-			// Extract and publish raw JSON-LD for validation
-			if s.jsonLdPublisher != nil {
-				s.publishJsonLd(ctx, job.ID, htmlContent)
+			// Transient failures are retried later while attempts remain; the
+			// last attempt proceeds with the API data (which is quite full), as
+			// the crawler already marked the job seen
+			if fetch.IsRetryable(err) && queue.WillRetry(ctx) {
+				return fmt.Errorf("fetch detail page: %w", err)
 			}
-
-			// Extract additional info from HTML (fallback/enrichment)
-			enrichJobData(job, htmlContent)
-
-			// Remove HTML content to avoid heavy Redis payload
-			job.HTMLContent = ""
+			log.Printf("[Vieclam24h] Failed to fetch HTML for %s: %v", job.ID, err)
+		}
+		if len(enrichment) > 0 && job.RawData == nil {
+			job.RawData = make(map[string]any)
+		}
+		for k, v := range enrichment {
+			job.RawData[k] = v
 		}
 
 		job.ExtractedAt = time.Now()
@@ -94,33 +128,98 @@ func (s *Scraper) Run(ctx context.Context) error {
 	})
 }
 
-func (s *Scraper) fetchHTML(ctx context.Context, url string) (string, error) {
+// blocked reports whether err comes from an open circuit; a block page that
+// did not trip the circuit is an ordinary failure
+func (s *Scraper) blocked(ctx context.Context, err error) bool {
+	if !errors.Is(err, breaker.ErrOpen) && !errors.Is(err, breaker.ErrBanned) {
+		return false
+	}
+	_, open := s.breaker.Allow(ctx)
+	return open != nil
+}
+
+// enrich returns the fields extracted from the job's detail page
+// A 304 for a cached page reuses the stored result without re-parsing
+func (s *Scraper) enrich(ctx context.Context, job *domain.RawJob) (map[string]any, error) {
+	cached, err := s.cache.Get(ctx, job.URL)
+	if err != nil {
+		log.Printf("[Vieclam24h] Detail cache read failed for %s: %v", job.ID, err)
+	}
+
+	page, err := s.fetchHTML(ctx, job.URL, cached)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.notModified {
+		log.Printf("[Vieclam24h] Detail page for %s not modified, reusing cached enrichment", job.ID)
+		if err := s.cache.Touch(ctx, cached); err != nil {
+			log.Printf("[Vieclam24h] Detail cache write failed for %s: %v", job.ID, err)
+		}
+		if cached.Result != nil {
+			return cached.Result, nil
+		}
+		body, err := cached.Content()
+		if err != nil {
+			return nil, err
+		}
+		if body == nil {
+			return nil, fmt.Errorf("not modified, but no cached result or body")
+		}
+		return extractEnrichment(job.ID, string(body)), nil
+	}
+
+	// Extract and publish raw JSON-LD for validation
+	if s.jsonLdPublisher != nil {
+		s.publishJsonLd(ctx, job.ID, page.html)
+	}
+
+	// Extract additional info from HTML (fallback/enrichment)
+	enrichment := extractEnrichment(job.ID, page.html)
+	if err := s.cache.Put(ctx, httpcache.NewEntry(job.URL, page.header, enrichment), []byte(page.html)); err != nil {
+		log.Printf("[Vieclam24h] Detail cache write failed for %s: %v", job.ID, err)
+	}
+	return enrichment, nil
+}
+
+// detailPage is a fetched or revalidated job page
+type detailPage struct {
+	html        string
+	header      http.Header
+	notModified bool
+}
+
+func (s *Scraper) fetchHTML(ctx context.Context, url string, cached *httpcache.Entry) (*detailPage, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return "", fmt.Errorf("create request: %w", err)
+		return nil, fmt.Errorf("create request: %w", err)
 	}
 
 	// Emulate browser
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8")
 	req.Header.Set("Accept-Language", "vi-VN,vi;q=0.9")
+	cached.Conditional(req)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("do request: %w", err)
+		return nil, fmt.Errorf("do request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return &detailPage{header: resp.Header, notModified: true}, nil
+	}
+	if err := fetch.CheckResponse(resp); err != nil {
+		return nil, err
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
 	if err != nil {
-		return "", fmt.Errorf("read body: %w", err)
+		return nil, fmt.Errorf("read body: %w", err)
 	}
 
-	return string(body), nil
+	return &detailPage{html: string(body), header: resp.Header}, nil
 }
 
 // publishJsonLd extracts JSON-LD from HTML and publishes to validation queue
@@ -153,10 +252,13 @@ func (s *Scraper) publishJsonLd(ctx context.Context, jobID string, html string)
 	})
 }
 
-func enrichJobData(job *domain.RawJob, html string) {
+// extractEnrichment reads the fields the API lacks from a detail page
+// (experience text, canonical URL and the JSON-LD JobPosting)
+func extractEnrichment(jobID string, html string) map[string]any {
+	data := make(map[string]any)
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
 	if err != nil {
-		return
+		return data
 	}
 
 	// Example: Extract breadcrumbs or specific meta tags not in API
@@ -165,10 +267,7 @@ func enrichJobData(job *domain.RawJob, html string) {
 
 	// Try to get canonical URL
 	if canonical, exists := doc.Find("link[rel='canonical']").Attr("href"); exists {
-		if job.RawData == nil {
-			job.RawData = make(map[string]any)
-		}
-		job.RawData["canonicalUrl"] = canonical
+		data["canonicalUrl"] = canonical
 	}
 
 	// Extract experience text from HTML (more reliable than API experienceRange ID)
@@ -184,10 +283,7 @@ func enrichJobData(job *domain.RawJob, html string) {
 			labelText := strings.TrimSpace(children.First().Text())
 			if labelText == "Kinh nghiệm" {
 				valueText := strings.TrimSpace(children.Eq(1).Text())
-				if job.RawData == nil {
-					job.RawData = make(map[string]any)
-				}
-				job.RawData["experienceText"] = valueText
+				data["experienceText"] = valueText
 			}
 		}
 	})
@@ -209,22 +305,18 @@ func enrichJobData(job *domain.RawJob, html string) {
 			return
 		}
 
-		if job.RawData == nil {
-			job.RawData = make(map[string]any)
-		}
-
 		// Extract fields
 		if jobPosting.Description != "" {
-			job.RawData["jobDescription"] = jobPosting.Description
+			data["jobDescription"] = jobPosting.Description
 		}
 		if jobPosting.JobBenefits != "" {
-			job.RawData["jobBenefits"] = jobPosting.JobBenefits
+			data["jobBenefits"] = jobPosting.JobBenefits
 		}
 		if jobPosting.Skills != "" {
-			job.RawData["skills"] = jobPosting.Skills
+			data["skills"] = jobPosting.Skills
 		}
 		if jobPosting.Qualifications != "" {
-			job.RawData["qualifications"] = jobPosting.Qualifications
+			data["qualifications"] = jobPosting.Qualifications
 		}
 		if jobPosting.Industry != "" {
 			// Split industry by comma for array indexing (GIN)
@@ -235,18 +327,18 @@ func enrichJobData(job *domain.RawJob, html string) {
 					industries = append(industries, trimmed)
 				}
 			}
-			job.RawData["industry"] = industries
+			data["industry"] = industries
 		}
 		if jobPosting.OccupationalCategory != "" {
-			job.RawData["occupationalCategory"] = jobPosting.OccupationalCategory
+			data["occupationalCategory"] = jobPosting.OccupationalCategory
 		}
 		if jobPosting.EmploymentType != "" {
-			job.RawData["employmentType"] = jobPosting.EmploymentType
+			data["employmentType"] = jobPosting.EmploymentType
 		}
 
 		// Company Website
 		if jobPosting.HiringOrganization.SameAs != "" {
-			job.RawData["companyWebsite"] = jobPosting.HiringOrganization.SameAs
+			data["companyWebsite"] = jobPosting.HiringOrganization.SameAs
 		}
 
 		// Structured Location - extract ALL locations as arrays (deduplicated)
@@ -267,25 +359,27 @@ func enrichJobData(job *domain.RawJob, html string) {
 				}
 			}
 			if len(cities) > 0 {
-				job.RawData["locationCity"] = cities
+				data["locationCity"] = cities
 			}
 			if len(districts) > 0 {
-				job.RawData["locationDistrict"] = districts
+				data["locationDistrict"] = districts
 			}
 		}
 
 		// Salary from JSON-LD baseSalary (prioritized over API)
 		if jobPosting.BaseSalary.Value.MinValue > 0 || jobPosting.BaseSalary.Value.MaxValue > 0 {
-			job.RawData["salaryMinJsonLd"] = jobPosting.BaseSalary.Value.MinValue
-			job.RawData["salaryMaxJsonLd"] = jobPosting.BaseSalary.Value.MaxValue
-			job.RawData["salaryCurrency"] = jobPosting.BaseSalary.Currency
+			data["salaryMinJsonLd"] = jobPosting.BaseSalary.Value.MinValue
+			data["salaryMaxJsonLd"] = jobPosting.BaseSalary.Value.MaxValue
+			data["salaryCurrency"] = jobPosting.BaseSalary.Currency
 		}
 		if jobPosting.BaseSalary.Value.Value != "" {
 			// Negotiable salary text like "Thỏa thuận"
-			job.RawData["salaryTextJsonLd"] = jobPosting.BaseSalary.Value.Value
-			job.RawData["isNegotiable"] = true
+			data["salaryTextJsonLd"] = jobPosting.BaseSalary.Value.Value
+			data["isNegotiable"] = true
 		}
 
-		log.Printf("[Vieclam24h] Extracted JSON-LD for %s: Description len=%d", job.ID, len(jobPosting.Description))
+		log.Printf("[Vieclam24h] Extracted JSON-LD for %s: Description len=%d", jobID, len(jobPosting.Description))
 	})
+
+	return data
 }
@@ -0,0 +1,267 @@
+package vieclam24h
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/common/fetch"
+	"github.com/redis/go-redis/v9"
+)
+
+// TokenProvider supplies the bearer token for the job list API
+type TokenProvider interface {
+	// Token returns a token that is believed to be valid
+	Token(ctx context.Context) (string, error)
+	// Refresh drops a token the API rejected and acquires a new one
+	Refresh(ctx context.Context, rejected string) (string, error)
+}
+
+// TokenRefreshError reports that no working token could be acquired
+type TokenRefreshError struct {
+	Err error
+}
+
+func (e *TokenRefreshError) Error() string {
+	return fmt.Sprintf("vieclam24h token refresh failed: %v", e.Err)
+}
+
+func (e *TokenRefreshError) Unwrap() error {
+	return e.Err
+}
+
+// StaticToken always returns the same token and cannot refresh
+type StaticToken string
+
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+func (t StaticToken) Refresh(ctx context.Context, rejected string) (string, error) {
+	return "", &TokenRefreshError{Err: errors.New("static token cannot be refreshed")}
+}
+
+// jwtPattern finds JWTs embedded in the site's HTML and bootstrap scripts
+var jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+
+// TokenConfig lists where tokens come from, in order of preference
+type TokenConfig struct {
+	// File is a secrets file holding the token (optional)
+	File string
+	// BootstrapURL is a public page whose HTML carries the anonymous token (default BaseURL)
+	BootstrapURL string
+	// Fallback is used when the other sources fail (default DefaultConfig().BearerToken)
+	Fallback string
+	// TTL caches tokens that carry no exp claim (default 6h)
+	TTL time.Duration
+}
+
+// CachedTokenProvider acquires tokens from a secrets file, the site bootstrap
+// page or a fallback, and shares them through Redis until they expire
+type CachedTokenProvider struct {
+	redis  *redis.Client
+	client *http.Client
+	config TokenConfig
+	key    string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewTokenProvider creates a provider; client fetches the bootstrap page
+func NewTokenProvider(rdb *redis.Client, client *http.Client, cfg TokenConfig) *CachedTokenProvider {
+	if cfg.BootstrapURL == "" {
+		cfg.BootstrapURL = BaseURL
+	}
+	if cfg.Fallback == "" {
+		cfg.Fallback = DefaultConfig().BearerToken
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 6 * time.Hour
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &CachedTokenProvider{
+		redis:  rdb,
+		client: client,
+		config: cfg,
+		key:    "crawler:token:vieclam24h",
+	}
+}
+
+// Token returns the cached token, or acquires and caches a new one
+func (p *CachedTokenProvider) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && time.Now().Before(p.expires) {
+		return p.token, nil
+	}
+
+	if p.redis != nil {
+		token, err := p.redis.Get(ctx, p.key).Result()
+		if err == nil && token != "" {
+			p.token, p.expires = token, p.expiry(token)
+			return token, nil
+		}
+		if err != nil && !errors.Is(err, redis.Nil) {
+			log.Printf("[Vieclam24h] Token cache read failed: %v", err)
+		}
+	}
+
+	token, err := p.acquire(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	p.store(ctx, token)
+	return token, nil
+}
+
+// Refresh drops the rejected token (locally and in Redis) and acquires a new one
+func (p *CachedTokenProvider) Refresh(ctx context.Context, rejected string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Another replica may already have refreshed it
+	if p.redis != nil {
+		token, err := p.redis.Get(ctx, p.key).Result()
+		if err == nil && token != "" && token != rejected {
+			p.token, p.expires = token, p.expiry(token)
+			return token, nil
+		}
+		p.redis.Del(ctx, p.key)
+	}
+	p.token = ""
+
+	token, err := p.acquire(ctx, rejected)
+	if err != nil {
+		return "", &TokenRefreshError{Err: err}
+	}
+	p.store(ctx, token)
+	log.Printf("[Vieclam24h] Bearer token refreshed (expires %s)", p.expires.Format(time.RFC3339))
+	return token, nil
+}
+
+// acquire tries the secrets file, the bootstrap page and the fallback,
+// skipping expired tokens and the one the API just rejected
+func (p *CachedTokenProvider) acquire(ctx context.Context, rejected string) (string, error) {
+	var errs []error
+	usable := func(from, token string) bool {
+		switch {
+		case token == "":
+			errs = append(errs, fmt.Errorf("%s: no token", from))
+		case token == rejected:
+			errs = append(errs, fmt.Errorf("%s: token was rejected", from))
+		case !p.expiry(token).After(time.Now()):
+			errs = append(errs, fmt.Errorf("%s: token expired", from))
+		default:
+			return true
+		}
+		return false
+	}
+
+	if p.config.File != "" {
+		data, err := os.ReadFile(p.config.File)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("token file: %w", err))
+		} else if token := strings.TrimSpace(string(data)); usable("token file", token) {
+			return token, nil
+		}
+	}
+
+	token, err := p.fetchBootstrap(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("bootstrap: %w", err))
+	} else if usable("bootstrap", token) {
+		return token, nil
+	}
+
+	if usable("fallback", p.config.Fallback) {
+		return p.config.Fallback, nil
+	}
+	return "", errors.Join(errs...)
+}
+
+// fetchBootstrap reads the anonymous token the web app embeds in its pages
+func (p *CachedTokenProvider) fetchBootstrap(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.BootstrapURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("User-Agent", DefaultConfig().UserAgent)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := fetch.CheckResponse(resp); err != nil {
+		return "", err
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 5<<20))
+	if err != nil {
+		return "", fmt.Errorf("read body: %w", err)
+	}
+
+	// Prefer a token whose payload names the vl24h channel
+	var first string
+	for _, match := range jwtPattern.FindAllString(string(body), -1) {
+		if first == "" {
+			first = match
+		}
+		if claims, ok := jwtClaims(match); ok && claims["channel_code"] != nil {
+			return match, nil
+		}
+	}
+	return first, nil
+}
+
+// store caches the token in memory and in Redis until it expires
+func (p *CachedTokenProvider) store(ctx context.Context, token string) {
+	p.token, p.expires = token, p.expiry(token)
+	if p.redis == nil {
+		return
+	}
+	if err := p.redis.Set(ctx, p.key, token, time.Until(p.expires)).Err(); err != nil {
+		log.Printf("[Vieclam24h] Token cache write failed: %v", err)
+	}
+}
+
+// expiry returns the exp claim minus a safety margin, or now+TTL without one
+func (p *CachedTokenProvider) expiry(token string) time.Time {
+	claims, ok := jwtClaims(token)
+	if exp, isNum := claims["exp"].(float64); ok && isNum && exp > 0 {
+		return time.Unix(int64(exp), 0).Add(-time.Minute)
+	}
+	return time.Now().Add(p.config.TTL)
+}
+
+// jwtClaims decodes a JWT payload without verifying its signature
+func jwtClaims(token string) (map[string]any, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, false
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, false
+	}
+	return claims, true
+}
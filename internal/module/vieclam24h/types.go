@@ -1,6 +1,10 @@
 package vieclam24h
 
-import "time"
+import (
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/common/fetch"
+)
 
 // Config holds crawler configuration
 type Config struct {
@@ -9,8 +13,9 @@ type Config struct {
 	RequestDelay time.Duration
 	UserAgent    string
 	BearerToken  string
-	Branch       string // "vl24h.north" or "vl24h.south"
-	VerboseLog   bool   // Log every job with status and URL
+	Branch       string        // "vl24h.north" or "vl24h.south"
+	VerboseLog   bool          // Log every job with status and URL
+	HTTP         fetch.Options // Shared rate limit, retry and proxy layers
 }
 
 // DefaultConfig returns default configuration
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -12,6 +13,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/project-tktt/go-crawler/internal/common/breaker"
+	"github.com/project-tktt/go-crawler/internal/common/fetch"
 	"github.com/project-tktt/go-crawler/internal/domain"
 	"github.com/project-tktt/go-crawler/internal/module"
 )
@@ -19,13 +22,17 @@ import (
 const (
 	// Real API endpoint discovered from browser network analysis
 	SearchAPIURL = "https://ms.vietnamworks.com/job-search/v1.0/search"
+	APIHost      = "ms.vietnamworks.com"
 	JobsPerPage  = 50
 )
 
 // Crawler implements job crawling for VietnamWorks
 type Crawler struct {
-	client *http.Client
-	config Config
+	client     *http.Client
+	config     Config
+	stopReason module.StopReason // Why the last run ended
+	startPage  int               // 0-based page to start from (set by Resume)
+	position   module.Position
 }
 
 // NewCrawler creates a new VietnamWorks crawler
@@ -41,7 +48,7 @@ func NewCrawler(cfg Config) *Crawler {
 	}
 
 	return &Crawler{
-		client: &http.Client{Timeout: 30 * time.Second},
+		client: &http.Client{Timeout: 30 * time.Second, Transport: cfg.HTTP.Transport(nil)},
 		config: cfg,
 	}
 }
@@ -57,34 +64,64 @@ func (c *Crawler) Crawl(ctx context.Context) ([]*domain.RawJob, error) {
 }
 
 // CrawlWithCallback fetches jobs page by page and calls handler after each page
+// The handler may return module.ErrStopCrawl to end pagination early
 func (c *Crawler) CrawlWithCallback(ctx context.Context, handler module.JobHandler) error {
 	totalJobCount := 0
+	c.stopReason = module.StopMaxPages
+	pageErrors := fetch.NewPageErrors(0)
+	startPage := c.startPage
+	c.startPage = 0 // Resume applies to one run only
+	if startPage > 0 {
+		log.Printf("[VietnamWorks] Resuming from page %d", startPage+1)
+	}
+
+	for page := startPage; page < c.config.MaxPages; page++ {
+		if ctx.Err() != nil {
+			c.stopReason = module.StopCancelled
+			break
+		}
 
-	for page := 0; page < c.config.MaxPages; page++ {
 		log.Printf("[VietnamWorks] Fetching page %d/%d", page+1, c.config.MaxPages)
 
 		jobs, totalPages, err := c.fetchPage(ctx, page)
 		if err != nil {
-			log.Printf("[VietnamWorks] Error on page %d: %v", page+1, err)
-			break
+			// Skip a page that kept failing after retries; give up on fatal errors
+			if err := pageErrors.Fail("[VietnamWorks]", page+1, err); err != nil {
+				log.Printf("[VietnamWorks] Stopping: %v", err)
+				c.stopReason = module.StopError
+				if errors.Is(err, breaker.ErrOpen) || errors.Is(err, breaker.ErrBanned) {
+					c.stopReason = module.StopBlocked
+				}
+				break
+			}
+			continue
 		}
+		pageErrors.OK()
 
 		if len(jobs) == 0 {
 			log.Printf("[VietnamWorks] No more jobs on page %d", page+1)
+			c.stopReason = module.StopCompleted
 			break
 		}
 
 		// Process jobs immediately via callback
+		totalJobCount += len(jobs)
+		c.position = module.Position{Page: page + 1}
 		if err := handler(jobs); err != nil {
+			if errors.Is(err, module.ErrStopCrawl) {
+				log.Printf("[VietnamWorks] Early stop after page %d (unchanged pages)", page+1)
+				c.stopReason = module.StopUnchanged
+				break
+			}
 			log.Printf("[VietnamWorks] Handler error on page %d: %v", page+1, err)
 		}
 
-		totalJobCount += len(jobs)
 		log.Printf("[VietnamWorks] Page %d: %d jobs processed", page+1, len(jobs))
 
 		// Stop if we've reached the last page
 		if page >= totalPages-1 {
 			log.Printf("[VietnamWorks] Reached last page (%d)", totalPages)
+			c.stopReason = module.StopCompleted
 			break
 		}
 
@@ -93,7 +130,7 @@ func (c *Crawler) CrawlWithCallback(ctx context.Context, handler module.JobHandl
 		time.Sleep(randomDelay)
 	}
 
-	log.Printf("[VietnamWorks] Crawled %d jobs total", totalJobCount)
+	log.Printf("[VietnamWorks] Crawled %d jobs total (stop: %s)", totalJobCount, c.stopReason)
 	return nil
 }
 
@@ -127,8 +164,8 @@ func (c *Crawler) fetchPage(ctx context.Context, page int) ([]*domain.RawJob, in
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, 0, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	if err := fetch.CheckResponse(resp); err != nil {
+		return nil, 0, err
 	}
 
 	respBody, err := io.ReadAll(resp.Body)
@@ -203,6 +240,22 @@ func (c *Crawler) fetchPage(ctx context.Context, page int) ([]*domain.RawJob, in
 	return jobs, searchResp.Meta.NbPages, nil
 }
 
+// Position returns the page currently handed to the handler (1-based)
+func (c *Crawler) Position() module.Position {
+	return c.position
+}
+
+// Resume makes the next run start after pos.Page
+func (c *Crawler) Resume(pos module.Position) {
+	c.startPage = pos.Page
+	c.position = pos
+}
+
+// StopReason returns why the last run ended
+func (c *Crawler) StopReason() module.StopReason {
+	return c.stopReason
+}
+
 // Source returns the source identifier
 func (c *Crawler) Source() domain.JobSource {
 	return domain.SourceVietnamWorks
@@ -0,0 +1,23 @@
+package vietnamworks
+
+import (
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/project-tktt/go-crawler/internal/module"
+)
+
+func init() {
+	module.Register(module.Registration{
+		Source:   domain.SourceVietnamWorks,
+		Schedule: "0 * * * *", // Every hour
+		// Not Incremental: the search API promises no result order, so a run
+		// cannot stop after unchanged pages without missing deeper updates
+		Factory: func(deps module.Deps) (module.Crawler, error) {
+			deps.LimitHosts(domain.SourceVietnamWorks, APIHost)
+			return NewCrawler(Config{
+				MaxPages:     1000,
+				RequestDelay: deps.Config.Crawler.RequestDelay,
+				HTTP:         deps.HTTP(domain.SourceVietnamWorks),
+			}), nil
+		},
+	})
+}
@@ -1,12 +1,17 @@
 package vietnamworks
 
-import "time"
+import (
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/common/fetch"
+)
 
 // Config holds VietnamWorks-specific configuration
 type Config struct {
 	MaxPages     int
 	RequestDelay time.Duration
 	UserAgent    string
+	HTTP         fetch.Options // Shared rate limit, retry and proxy layers
 }
 
 // SearchRequest is the payload for VietnamWorks search API
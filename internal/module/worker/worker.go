@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -15,13 +16,17 @@ import (
 
 // Worker processes jobs from queue and indexes to storage
 type Worker struct {
-	consumer   *queue.Consumer
+	consumer   queue.Consumer
 	normalizer *normalizer.Normalizer
 	cleaner    *cleaner.Cleaner
 	indexer    indexer.Indexer
 
 	batchSize   int
 	concurrency int
+
+	deadLetters queue.DeadLetterer
+	stage       string
+	retrier     *queue.Retrier
 }
 
 // Config holds worker configuration
@@ -32,7 +37,7 @@ type Config struct {
 
 // NewWorker creates a new worker
 func NewWorker(
-	consumer *queue.Consumer,
+	consumer queue.Consumer,
 	norm *normalizer.Normalizer,
 	clean *cleaner.Cleaner,
 	idx indexer.Indexer,
@@ -55,6 +60,21 @@ func NewWorker(
 	}
 }
 
+// WithDeadLetters dead-letters jobs that fail to normalize or are rejected by
+// the indexer as stage, instead of dropping them
+func (w *Worker) WithDeadLetters(dl queue.DeadLetterer, stage string) *Worker {
+	w.deadLetters = dl
+	w.stage = stage
+	return w
+}
+
+// WithRetries schedules jobs of a failed bulk request for a delayed retry
+// instead of leaving them in flight until the visibility timeout
+func (w *Worker) WithRetries(r *queue.Retrier) *Worker {
+	w.retrier = r
+	return w
+}
+
 // Run starts the worker pool
 func (w *Worker) Run(ctx context.Context) error {
 	log.Printf("Starting worker pool with %d workers", w.concurrency)
@@ -100,35 +120,59 @@ func (w *Worker) runSingle(ctx context.Context, workerID int) error {
 		default:
 		}
 
-		// ConsumeBatch uses BRPOP for first item (blocking), so no CPU spinning
-		rawJobs, err := w.consumer.ConsumeBatch(ctx, w.batchSize)
+		// ConsumeBatch blocks for the first item (BLMOVE/XREADGROUP), so no CPU spinning
+		deliveries, err := w.consumer.ConsumeBatch(ctx, w.batchSize)
 		if err != nil {
 			log.Printf("Worker %d consume error: %v", workerID, err)
 			continue
 		}
 
-		if len(rawJobs) == 0 {
-			continue // Timeout from BRPOP, try again
+		if len(deliveries) == 0 {
+			continue // Timeout, try again
 		}
 
-		log.Printf("Worker %d processing %d jobs", workerID, len(rawJobs))
+		log.Printf("Worker %d processing %d jobs", workerID, len(deliveries))
 
-		// Process and index jobs
-		jobs := w.processJobs(rawJobs)
+		// Process and index jobs; unacked jobs go back to the queue after the
+		// visibility timeout
+		jobs, byID := w.processJobs(ctx, deliveries)
 		if len(jobs) > 0 {
-			if err := w.indexer.BulkIndex(ctx, jobs); err != nil {
-				log.Printf("Worker %d index error: %v", workerID, err)
-			} else {
+			err := w.indexer.BulkIndex(ctx, jobs)
+			var bulkErr *indexer.BulkError
+			switch {
+			case errors.As(err, &bulkErr):
+				log.Printf("Worker %d indexed %d jobs, %d rejected", workerID, len(jobs)-len(bulkErr.Failed), len(bulkErr.Failed))
+				for id, reason := range bulkErr.Failed {
+					for _, d := range byID[id] {
+						w.deadLetter(ctx, d, fmt.Errorf("index: %s", reason))
+					}
+				}
+			case err != nil && w.retrier != nil && ctx.Err() == nil:
+				log.Printf("Worker %d index error, retrying %d jobs later: %v", workerID, len(jobs), err)
+				deliveries = w.retry(ctx, deliveries, byID, err)
+			case err != nil:
+				log.Printf("Worker %d index error, %d jobs left in flight for retry: %v", workerID, len(deliveries), err)
+				continue
+			default:
 				log.Printf("Worker %d indexed %d jobs", workerID, len(jobs))
 			}
 		}
+
+		// Failed jobs are acked too (after dead-lettering): retrying will not fix them
+		if err := w.consumer.Ack(ctx, deliveries...); err != nil {
+			log.Printf("Worker %d %v", workerID, err)
+		}
 	}
 }
 
-func (w *Worker) processJobs(rawJobs []*domain.RawJob) []*domain.Job {
-	jobs := make([]*domain.Job, 0, len(rawJobs))
+// processJobs normalizes deliveries into jobs, returned with the deliveries
+// each job ID came from
+func (w *Worker) processJobs(ctx context.Context, deliveries []*queue.Delivery) ([]*domain.Job, map[string][]*queue.Delivery) {
+	jobs := make([]*domain.Job, 0, len(deliveries))
+	byID := make(map[string][]*queue.Delivery, len(deliveries))
 
-	for _, raw := range rawJobs {
+	for _, d := range deliveries {
+		raw := d.Job
 		// Clean raw data
 		if raw.RawData != nil {
 			raw.RawData = w.cleaner.CleanMap(raw.RawData)
@@ -138,6 +182,7 @@ func (w *Worker) processJobs(rawJobs []*domain.RawJob) []*domain.Job {
 		job, err := w.normalizer.Normalize(raw)
 		if err != nil {
 			log.Printf("Normalize error for %s: %v", raw.ID, err)
+			w.deadLetter(ctx, d, fmt.Errorf("normalize: %w", err))
 			continue
 		}
 
@@ -147,7 +192,40 @@ func (w *Worker) processJobs(rawJobs []*domain.RawJob) []*domain.Job {
 		job.Benefits = w.cleaner.CleanToText(job.Benefits)
 
 		jobs = append(jobs, job)
+		byID[job.ID] = append(byID[job.ID], d)
 	}
 
-	return jobs
+	return jobs, byID
+}
+
+// retry schedules the jobs of a failed bulk request for another attempt and
+// returns the deliveries that can be acked (all but those it failed to schedule)
+func (w *Worker) retry(ctx context.Context, deliveries []*queue.Delivery, byID map[string][]*queue.Delivery, cause error) []*queue.Delivery {
+	unscheduled := make(map[*queue.Delivery]bool)
+	for _, ds := range byID {
+		for _, d := range ds {
+			if err := w.retrier.Retry(ctx, d, fmt.Errorf("index: %w", cause)); err != nil {
+				log.Printf("Failed to schedule retry of %s, left in flight: %v", d.Job.ID, err)
+				unscheduled[d] = true
+			}
+		}
+	}
+
+	ackable := make([]*queue.Delivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		if !unscheduled[d] {
+			ackable = append(ackable, d)
+		}
+	}
+	return ackable
+}
+
+// deadLetter stores a failed job when a dead-letter store is set
+func (w *Worker) deadLetter(ctx context.Context, d *queue.Delivery, cause error) {
+	if w.deadLetters == nil {
+		return
+	}
+	if err := w.deadLetters.DeadLetter(ctx, d.DeadLetter(w.stage, cause)); err != nil {
+		log.Printf("Failed to dead-letter %s: %v", d.Job.ID, err)
+	}
 }
@@ -2,39 +2,186 @@ package queue
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/project-tktt/go-crawler/internal/domain"
 	"github.com/redis/go-redis/v9"
 )
 
-// Consumer consumes jobs from Redis queue
-type Consumer struct {
+// Consumer takes jobs off a queue
+// Implemented by RedisConsumer, ReliableConsumer, StreamConsumer and MemoryQueue
+type Consumer interface {
+	// ConsumeBatch blocks up to the consumer's timeout for the first job, then
+	// takes up to maxBatch jobs without waiting; no jobs means a timeout
+	ConsumeBatch(ctx context.Context, maxBatch int) ([]*Delivery, error)
+	// Ack confirms handled jobs (a no-op for queues without redelivery)
+	Ack(ctx context.Context, deliveries ...*Delivery) error
+	// Run hands jobs to handler one at a time until ctx is cancelled; the
+	// handler's ctx carries the run and trace of the message
+	Run(ctx context.Context, handler Handler) error
+}
+
+// Handler handles one job; jobs it publishes with ctx stay in the run and
+// trace of the message it came in
+type Handler func(ctx context.Context, job *domain.RawJob) error
+
+// Delivery is a job taken from the queue; Ack it once it has been handled
+type Delivery struct {
+	Job *domain.RawJob
+	// Envelope holds the message metadata (schema 0 for legacy payloads)
+	Envelope Envelope
+	queue    string
+	payload  string
+	id       string // Stream entry ID (stream consumers)
+	// requeue is where the job goes to reach this consumer again, when that is
+	// not queue (a stream group's own stream)
+	requeue string
+}
+
+// newDelivery decodes a payload taken from queue
+func newDelivery(queue, payload, id string) (*Delivery, error) {
+	d := &Delivery{queue: queue, payload: payload, id: id}
+	env, err := decodeEnvelope([]byte(payload))
+	if err != nil {
+		return d, err
+	}
+	d.Envelope = env
+	d.Job = env.Job
+	return d, nil
+}
+
+// Payload returns the delivery's payload as it was read from the queue
+func (d *Delivery) Payload() string {
+	return d.payload
+}
+
+// DeadLetter describes the delivery as a dead letter of stage failing with cause
+func (d *Delivery) DeadLetter(stage string, cause error) *DeadLetter {
+	dl := &DeadLetter{
+		Stage:   stage,
+		Queue:   d.queue,
+		RunID:   d.Envelope.RunID,
+		Error:   cause.Error(),
+		Payload: d.payload,
+	}
+	if d.requeue != "" {
+		dl.Queue = d.requeue
+	}
+	if d.Job != nil {
+		dl.Source = string(d.Job.Source)
+		dl.JobID = d.Job.ID
+	}
+	return dl
+}
+
+// run is the Consumer.Run loop: jobs are acked once handler succeeds; failed
+// jobs are scheduled for retry or dead-lettered, then acked, when the
+// consumer has a retrier or a dead-letter store, else left for redelivery
+// (where the queue supports it)
+func run(ctx context.Context, c Consumer, name string, failed failures, handler Handler) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		deliveries, err := c.ConsumeBatch(ctx, 1)
+		if err != nil {
+			return fmt.Errorf("consume: %w", err)
+		}
+
+		for _, d := range deliveries {
+			hctx := withEnvelope(ctx, d.Envelope, failed.retrier != nil && failed.retrier.willRetry(d))
+			err := handler(hctx, d.Job)
+			if err != nil {
+				// Log error but continue processing
+				log.Printf("[Queue] %s: handler error: %v", name, err)
+			}
+			if err == nil || (ctx.Err() == nil && failed.handle(ctx, d, err)) {
+				if err := c.Ack(ctx, d); err != nil {
+					log.Printf("[Queue] %s: %v", name, err)
+				}
+			}
+
+			// Paused with no delivery in flight, so none is reclaimed meanwhile
+			var pause *PauseError
+			if errors.As(err, &pause) {
+				log.Printf("[Queue] %s: pausing: %v", name, pause.Err)
+				if err := pause.Wait(ctx); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// PauseError is a handler error after which the consumer stops taking jobs
+// until Wait returns (e.g. while the source's circuit is open); the failed
+// job itself is retried or dead-lettered as usual
+type PauseError struct {
+	Err  error
+	Wait func(ctx context.Context) error
+}
+
+// Pause wraps a handler error to pause consumption until wait returns
+func Pause(err error, wait func(ctx context.Context) error) error {
+	return &PauseError{Err: err, Wait: wait}
+}
+
+func (e *PauseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PauseError) Unwrap() error {
+	return e.Err
+}
+
+// RedisConsumer consumes jobs from a Redis list with BRPOP
+// A job is gone from Redis once popped (at-most-once); use ReliableConsumer
+// when a crash must not lose jobs
+type RedisConsumer struct {
 	client    *redis.Client
 	queueName string
 	timeout   time.Duration
+	failures  failures
 }
 
 // NewConsumer creates a new queue consumer
-func NewConsumer(client *redis.Client, queueName string, timeout time.Duration) *Consumer {
+func NewConsumer(client *redis.Client, queueName string, timeout time.Duration) *RedisConsumer {
 	if queueName == "" {
 		queueName = "jobs:raw"
 	}
 	if timeout == 0 {
 		timeout = 5 * time.Second
 	}
-	return &Consumer{
+	return &RedisConsumer{
 		client:    client,
 		queueName: queueName,
 		timeout:   timeout,
 	}
 }
 
+// WithDeadLetters dead-letters malformed jobs and jobs the Run handler fails
+// on as stage; without it they are logged and dropped
+func (c *RedisConsumer) WithDeadLetters(sink DeadLetterer, stage string) *RedisConsumer {
+	c.failures.sink, c.failures.stage = sink, stage
+	return c
+}
+
+// WithRetries schedules jobs the Run handler fails on for a delayed retry,
+// dead-lettered once out of attempts
+func (c *RedisConsumer) WithRetries(r *Retrier) *RedisConsumer {
+	c.failures.retrier = r
+	return c
+}
+
 // Consume blocks and waits for a job from the queue
 // Returns nil, nil if timeout occurs with no job
-func (c *Consumer) Consume(ctx context.Context) (*domain.RawJob, error) {
+func (c *RedisConsumer) Consume(ctx context.Context) (*domain.RawJob, error) {
 	result, err := c.client.BRPop(ctx, c.timeout, c.queueName).Result()
 	if err != nil {
 		if err == redis.Nil {
@@ -47,33 +194,35 @@ func (c *Consumer) Consume(ctx context.Context) (*domain.RawJob, error) {
 		return nil, nil
 	}
 
-	var job domain.RawJob
-	if err := json.Unmarshal([]byte(result[1]), &job); err != nil {
-		return nil, fmt.Errorf("unmarshal job: %w", err)
+	d, err := newDelivery(c.queueName, result[1], "")
+	if err != nil {
+		c.failures.send(ctx, d, err)
+		return nil, err
 	}
-
-	return &job, nil
+	return d.Job, nil
 }
 
 // ConsumeBatch consumes up to maxBatch jobs from the queue
 // Uses BRPOP to block-wait for first item (prevents CPU spinning)
 // Then uses RPOP to quickly grab remaining items for the batch
-func (c *Consumer) ConsumeBatch(ctx context.Context, maxBatch int) ([]*domain.RawJob, error) {
-	jobs := make([]*domain.RawJob, 0, maxBatch)
+func (c *RedisConsumer) ConsumeBatch(ctx context.Context, maxBatch int) ([]*Delivery, error) {
+	deliveries := make([]*Delivery, 0, maxBatch)
 
 	// First item: use BRPOP to block until available (prevents CPU spinning)
 	result, err := c.client.BRPop(ctx, c.timeout, c.queueName).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return jobs, nil // Timeout, no jobs
+			return deliveries, nil // Timeout, no jobs
 		}
 		return nil, fmt.Errorf("brpop: %w", err)
 	}
 
 	if len(result) >= 2 {
-		var job domain.RawJob
-		if err := json.Unmarshal([]byte(result[1]), &job); err == nil {
-			jobs = append(jobs, &job)
+		if d, err := newDelivery(c.queueName, result[1], ""); err == nil {
+			deliveries = append(deliveries, d)
+		} else {
+			log.Printf("[Queue] %s: dropping malformed item: %v", c.queueName, err)
+			c.failures.send(ctx, d, err)
 		}
 	}
 
@@ -84,41 +233,28 @@ func (c *Consumer) ConsumeBatch(ctx context.Context, maxBatch int) ([]*domain.Ra
 			if err == redis.Nil {
 				break // No more jobs
 			}
-			return jobs, fmt.Errorf("rpop: %w", err)
+			return deliveries, fmt.Errorf("rpop: %w", err)
 		}
 
-		var job domain.RawJob
-		if err := json.Unmarshal([]byte(result), &job); err != nil {
-			continue // Skip malformed jobs
+		d, err := newDelivery(c.queueName, result, "")
+		if err != nil {
+			log.Printf("[Queue] %s: dropping malformed item: %v", c.queueName, err)
+			c.failures.send(ctx, d, err)
+			continue
 		}
 
-		jobs = append(jobs, &job)
+		deliveries = append(deliveries, d)
 	}
 
-	return jobs, nil
+	return deliveries, nil
 }
 
-// Run starts a continuous consumer loop
-func (c *Consumer) Run(ctx context.Context, handler func(*domain.RawJob) error) error {
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		job, err := c.Consume(ctx)
-		if err != nil {
-			return fmt.Errorf("consume: %w", err)
-		}
-
-		if job == nil {
-			continue // Timeout, try again
-		}
+// Ack is a no-op: popped jobs are already gone from the list
+func (c *RedisConsumer) Ack(ctx context.Context, deliveries ...*Delivery) error {
+	return nil
+}
 
-		if err := handler(job); err != nil {
-			// Log error but continue processing
-			fmt.Printf("handler error: %v\n", err)
-		}
-	}
+// Run starts a continuous consumer loop
+func (c *RedisConsumer) Run(ctx context.Context, handler Handler) error {
+	return run(ctx, c, c.queueName, c.failures, handler)
 }
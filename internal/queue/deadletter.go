@@ -0,0 +1,419 @@
+package queue
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DeadLetter is a job a pipeline stage gave up on, kept with its original
+// payload so it can be inspected and replayed once the cause is fixed
+type DeadLetter struct {
+	// ID identifies the job within its stage: <source>:<job id>, or a hash
+	// of the payload when it could not be decoded
+	ID    string `json:"id"`
+	Stage string `json:"stage"`
+	// Queue replay pushes the payload to: the one it was taken from, or the
+	// consumer group's own stream for stream consumers
+	Queue  string `json:"queue"`
+	Source string `json:"source,omitempty"`
+	JobID  string `json:"job_id,omitempty"`
+	// RunID is the crawl run that produced the job (from its envelope)
+	RunID string `json:"run_id,omitempty"`
+	Error string `json:"error"`
+	// Attempts counts failures of this job in the stage, across replays
+	// When storing, it is the number of failures being reported (default 1)
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+	Payload  string    `json:"payload"`
+}
+
+// DeadLetterer stores jobs a stage gave up on
+// Implemented by DeadLetterStore
+type DeadLetterer interface {
+	DeadLetter(ctx context.Context, dl *DeadLetter) error
+}
+
+// DeadLetterConfig holds dead-letter store options
+type DeadLetterConfig struct {
+	// Prefix of the Redis keys (default "dlq")
+	Prefix string
+	// MaxLen caps the entries kept per stage, oldest dropped first (default 10000)
+	MaxLen int
+}
+
+// addDeadLetter stores an entry and drops the oldest ones past the cap
+// KEYS: entries hash, failed_at zset, attempts hash; ARGV: id, entry, score, max length
+var addDeadLetter = redis.NewScript(`
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+redis.call("ZADD", KEYS[2], ARGV[3], ARGV[1])
+local excess = redis.call("ZCARD", KEYS[2]) - tonumber(ARGV[4])
+if excess <= 0 then
+	return 0
+end
+local ids = redis.call("ZRANGE", KEYS[2], 0, excess - 1)
+for _, id in ipairs(ids) do
+	redis.call("ZREM", KEYS[2], id)
+	redis.call("HDEL", KEYS[1], id)
+	redis.call("HDEL", KEYS[3], id)
+end
+return #ids
+`)
+
+// DeadLetterStore keeps dead letters in Redis, per stage:
+//
+//	dlq:<stage>          hash id -> entry JSON
+//	dlq:<stage>:index    zset of ids scored by failure time
+//	dlq:<stage>:attempts hash id -> failure count (kept across replays)
+//	dlq:stages           set of stages with entries
+//
+// A job failing again replaces its entry and bumps its attempt count
+type DeadLetterStore struct {
+	client *redis.Client
+	config DeadLetterConfig
+}
+
+// DeadLetterFilter selects dead letters; zero fields match everything
+type DeadLetterFilter struct {
+	IDs    []string
+	Source string
+	JobID  string
+	RunID  string
+	// Error matches a substring of the error, ignoring case
+	Error string
+	// Since keeps entries that failed at or after this time
+	Since time.Time
+	// Limit caps the number of entries returned (0 = all)
+	Limit int
+}
+
+// NewDeadLetterStore creates a Redis dead-letter store
+func NewDeadLetterStore(client *redis.Client, cfg DeadLetterConfig) *DeadLetterStore {
+	if cfg.Prefix == "" {
+		cfg.Prefix = "dlq"
+	}
+	if cfg.MaxLen <= 0 {
+		cfg.MaxLen = 10000
+	}
+	return &DeadLetterStore{client: client, config: cfg}
+}
+
+// DeadLetter stores a failed job; ID and FailedAt are filled in, and
+// Attempts is added to the job's count in the stage
+func (s *DeadLetterStore) DeadLetter(ctx context.Context, dl *DeadLetter) error {
+	if dl.Stage == "" {
+		return fmt.Errorf("dead letter without stage")
+	}
+	if dl.ID == "" {
+		dl.ID = deadLetterID(dl)
+	}
+	if dl.FailedAt.IsZero() {
+		dl.FailedAt = time.Now()
+	}
+
+	attempts, err := s.client.HIncrBy(ctx, s.attemptsKey(dl.Stage), dl.ID, int64(max(dl.Attempts, 1))).Result()
+	if err != nil {
+		return fmt.Errorf("count attempts: %w", err)
+	}
+	dl.Attempts = int(attempts)
+
+	data, err := json.Marshal(dl)
+	if err != nil {
+		return fmt.Errorf("marshal dead letter: %w", err)
+	}
+	keys := []string{s.entriesKey(dl.Stage), s.indexKey(dl.Stage), s.attemptsKey(dl.Stage)}
+	if err := addDeadLetter.Run(ctx, s.client, keys, dl.ID, data, dl.FailedAt.UnixMilli(), s.config.MaxLen).Err(); err != nil {
+		return fmt.Errorf("store dead letter: %w", err)
+	}
+	if err := s.client.SAdd(ctx, s.stagesKey(), dl.Stage).Err(); err != nil {
+		return fmt.Errorf("register stage: %w", err)
+	}
+	return nil
+}
+
+// Stages returns the stages that have dead letters, with their counts
+func (s *DeadLetterStore) Stages(ctx context.Context) (map[string]int64, error) {
+	stages, err := s.client.SMembers(ctx, s.stagesKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list stages: %w", err)
+	}
+	counts := make(map[string]int64, len(stages))
+	for _, stage := range stages {
+		n, err := s.Count(ctx, stage)
+		if err != nil {
+			return nil, err
+		}
+		counts[stage] = n
+	}
+	return counts, nil
+}
+
+// Count returns the number of dead letters of a stage
+func (s *DeadLetterStore) Count(ctx context.Context, stage string) (int64, error) {
+	n, err := s.client.ZCard(ctx, s.indexKey(stage)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("count %s: %w", stage, err)
+	}
+	return n, nil
+}
+
+// Get returns a dead letter by ID, or nil when there is none
+func (s *DeadLetterStore) Get(ctx context.Context, stage, id string) (*DeadLetter, error) {
+	data, err := s.client.HGet(ctx, s.entriesKey(stage), id).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get %s: %w", id, err)
+	}
+	var dl DeadLetter
+	if err := json.Unmarshal([]byte(data), &dl); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", id, err)
+	}
+	return &dl, nil
+}
+
+// List returns the dead letters of a stage matching f, newest first
+func (s *DeadLetterStore) List(ctx context.Context, stage string, f DeadLetterFilter) ([]*DeadLetter, error) {
+	if len(f.IDs) > 0 {
+		return s.listIDs(ctx, stage, f)
+	}
+
+	from := "-inf"
+	if !f.Since.IsZero() {
+		from = strconv.FormatInt(f.Since.UnixMilli(), 10)
+	}
+	const pageSize = 200
+
+	var result []*DeadLetter
+	for offset := int64(0); ; offset += pageSize {
+		ids, err := s.client.ZRevRangeByScore(ctx, s.indexKey(stage), &redis.ZRangeBy{
+			Min:    from,
+			Max:    "+inf",
+			Offset: offset,
+			Count:  pageSize,
+		}).Result()
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", stage, err)
+		}
+		entries, err := s.load(ctx, stage, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, dl := range entries {
+			if !f.matches(dl) {
+				continue
+			}
+			result = append(result, dl)
+			if f.Limit > 0 && len(result) >= f.Limit {
+				return result, nil
+			}
+		}
+		if len(ids) < pageSize {
+			return result, nil
+		}
+	}
+}
+
+// Replay pushes the jobs of matching dead letters back to the queues they
+// came from, through the publisher open returns for each queue, and removes
+// them; the envelope keeps its ID and starts a new round of attempts (legacy
+// payloads are wrapped), and the stage's attempt counts are kept so a job
+// failing again shows it
+// Entries whose payload cannot be decoded stay in the store and are counted
+// as skipped
+func (s *DeadLetterStore) Replay(ctx context.Context, stage string, f DeadLetterFilter, open func(queue string) (Publisher, error)) (replayed, skipped int, err error) {
+	entries, err := s.List(ctx, stage, f)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, dl := range entries {
+		env, err := decodeEnvelope([]byte(dl.Payload))
+		if err != nil {
+			log.Printf("[DLQ] %s: skipping %s, payload is unreadable: %v", stage, dl.ID, err)
+			skipped++
+			continue
+		}
+		publisher, err := open(dl.Queue)
+		if err != nil {
+			return replayed, skipped, fmt.Errorf("replay %s: %w", dl.ID, err)
+		}
+		if err := publisher.PublishEnvelope(ctx, env.replayed(DefaultProducer())); err != nil {
+			return replayed, skipped, fmt.Errorf("replay %s to %s: %w", dl.ID, dl.Queue, err)
+		}
+		if err := s.remove(ctx, stage, false, dl.ID); err != nil {
+			return replayed, skipped, err
+		}
+		replayed++
+	}
+	return replayed, skipped, nil
+}
+
+// Purge deletes matching dead letters together with their attempt counts
+func (s *DeadLetterStore) Purge(ctx context.Context, stage string, f DeadLetterFilter) (int, error) {
+	entries, err := s.List(ctx, stage, f)
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]string, len(entries))
+	for i, dl := range entries {
+		ids[i] = dl.ID
+	}
+	if err := s.remove(ctx, stage, true, ids...); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+func (s *DeadLetterStore) listIDs(ctx context.Context, stage string, f DeadLetterFilter) ([]*DeadLetter, error) {
+	entries, err := s.load(ctx, stage, f.IDs)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*DeadLetter, 0, len(entries))
+	for _, dl := range entries {
+		if !f.Since.IsZero() && dl.FailedAt.Before(f.Since) {
+			continue
+		}
+		if f.matches(dl) {
+			result = append(result, dl)
+		}
+	}
+	if f.Limit > 0 && len(result) > f.Limit {
+		result = result[:f.Limit]
+	}
+	return result, nil
+}
+
+// load fetches entries by ID, skipping missing ones
+func (s *DeadLetterStore) load(ctx context.Context, stage string, ids []string) ([]*DeadLetter, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	values, err := s.client.HMGet(ctx, s.entriesKey(stage), ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("load %s: %w", stage, err)
+	}
+	entries := make([]*DeadLetter, 0, len(values))
+	for i, v := range values {
+		data, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var dl DeadLetter
+		if err := json.Unmarshal([]byte(data), &dl); err != nil {
+			log.Printf("[DLQ] %s: unreadable entry %s: %v", stage, ids[i], err)
+			continue
+		}
+		entries = append(entries, &dl)
+	}
+	return entries, nil
+}
+
+// remove deletes entries; their attempt counts go too when forget is set
+func (s *DeadLetterStore) remove(ctx context.Context, stage string, forget bool, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	members := make([]any, len(ids))
+	for i, id := range ids {
+		members[i] = id
+	}
+	pipe := s.client.TxPipeline()
+	pipe.HDel(ctx, s.entriesKey(stage), ids...)
+	pipe.ZRem(ctx, s.indexKey(stage), members...)
+	if forget {
+		pipe.HDel(ctx, s.attemptsKey(stage), ids...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("remove from %s: %w", stage, err)
+	}
+	return nil
+}
+
+func (s *DeadLetterStore) entriesKey(stage string) string {
+	return s.config.Prefix + ":" + stage
+}
+
+func (s *DeadLetterStore) indexKey(stage string) string {
+	return s.config.Prefix + ":" + stage + ":index"
+}
+
+func (s *DeadLetterStore) attemptsKey(stage string) string {
+	return s.config.Prefix + ":" + stage + ":attempts"
+}
+
+func (s *DeadLetterStore) stagesKey() string {
+	return s.config.Prefix + ":stages"
+}
+
+func (f DeadLetterFilter) matches(dl *DeadLetter) bool {
+	if f.Source != "" && !strings.EqualFold(dl.Source, f.Source) {
+		return false
+	}
+	if f.JobID != "" && dl.JobID != f.JobID {
+		return false
+	}
+	if f.RunID != "" && dl.RunID != f.RunID {
+		return false
+	}
+	if f.Error != "" && !strings.Contains(strings.ToLower(dl.Error), strings.ToLower(f.Error)) {
+		return false
+	}
+	return true
+}
+
+// deadLetterID keys a dead letter by job, so a job failing again replaces
+// its entry instead of piling up copies
+func deadLetterID(dl *DeadLetter) string {
+	if dl.JobID != "" && dl.Source != "" {
+		return dl.Source + ":" + dl.JobID
+	}
+	if dl.JobID != "" {
+		return dl.JobID
+	}
+	sum := sha1.Sum([]byte(dl.Payload))
+	return "payload:" + hex.EncodeToString(sum[:8])
+}
+
+// failures is what a consumer does with jobs it cannot handle: retry them
+// later, dead-letter them, or (neither set) drop or leave them
+type failures struct {
+	sink    DeadLetterer
+	stage   string
+	retrier *Retrier
+}
+
+// send dead-letters a delivery and reports whether it was stored
+func (f failures) send(ctx context.Context, d *Delivery, cause error) bool {
+	if f.sink == nil {
+		return false
+	}
+	if err := f.sink.DeadLetter(ctx, d.DeadLetter(f.stage, cause)); err != nil {
+		log.Printf("[DLQ] %s: failed to dead-letter a job from %s: %v", f.stage, d.queue, err)
+		return false
+	}
+	return true
+}
+
+// handle retries or dead-letters a job its handler failed on and reports
+// whether it may be acked
+func (f failures) handle(ctx context.Context, d *Delivery, cause error) bool {
+	if f.retrier == nil {
+		return f.send(ctx, d, cause)
+	}
+	if err := f.retrier.Retry(ctx, d, cause); err != nil {
+		log.Printf("[Retry] %s: %v", f.retrier.config.Stage, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,207 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// openPublishers opens replay targets the way cmd/dlq does
+func openPublishers(ctx context.Context, client *redis.Client) func(queue string) (Publisher, error) {
+	return func(queueName string) (Publisher, error) {
+		return OpenPublisher(ctx, client, queueName, 0)
+	}
+}
+
+func TestDeadLetterReplayToGroupStream(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	store := NewDeadLetterStore(client, DeadLetterConfig{})
+
+	// Two groups read the shared stream; the indexer fails on the job
+	indexer := NewStreamConsumer(client, "jobs:raw", StreamConfig{Group: "indexer", Name: "a", Timeout: 50 * time.Millisecond})
+	archiver := NewStreamConsumer(client, "jobs:raw", StreamConfig{Group: "archiver", Name: "a", Timeout: 50 * time.Millisecond})
+	for _, c := range []*StreamConsumer{indexer, archiver} {
+		if err := c.EnsureGroup(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	publishStream(t, client, "jobs:raw", "1")
+
+	deliveries, err := indexer.ConsumeBatch(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("consumed %v, want 1 job", deliveryIDs(deliveries))
+	}
+	if err := store.DeadLetter(ctx, deliveries[0].DeadLetter("indexer", errors.New("mapping error"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexer.Ack(ctx, deliveries...); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed, skipped, err := store.Replay(ctx, "indexer", DeadLetterFilter{}, openPublishers(ctx, client))
+	if err != nil || replayed != 1 || skipped != 0 {
+		t.Fatalf("replay = %d replayed, %d skipped, %v; want 1, 0", replayed, skipped, err)
+	}
+	if n, _ := store.Count(ctx, "indexer"); n != 0 {
+		t.Errorf("dead letters after replay = %d, want 0", n)
+	}
+	if n, _ := client.XLen(ctx, "jobs:raw").Result(); n != 1 {
+		t.Errorf("shared stream length = %d, want 1 (nothing republished there)", n)
+	}
+
+	again, err := indexer.ConsumeBatch(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again) != 1 || again[0].queue != indexer.GroupStream() {
+		t.Fatalf("indexer got %d jobs, want the replayed one from its group stream", len(again))
+	}
+	if env := again[0].Envelope; env.ID != deliveries[0].Envelope.ID || env.Attempts != 1 {
+		t.Errorf("replayed envelope %s attempt %d, want %s attempt 1", env.ID, env.Attempts, deliveries[0].Envelope.ID)
+	}
+
+	// The other group sees the job once, from the shared stream only
+	other, err := archiver.ConsumeBatch(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(other) != 1 || other[0].queue != "jobs:raw" {
+		t.Errorf("archiver got %d jobs, want the original one only", len(other))
+	}
+}
+
+func TestDeadLetterReplaySkipsUnreadable(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	store := NewDeadLetterStore(client, DeadLetterConfig{})
+
+	good := testDelivery(t, "jobs:raw", "1", 1).DeadLetter("worker", errors.New("timeout"))
+	bad := &DeadLetter{Stage: "worker", Queue: "jobs:raw", Error: "unmarshal job", Payload: "{not json"}
+	for _, dl := range []*DeadLetter{good, bad} {
+		if err := store.DeadLetter(ctx, dl); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replayed, skipped, err := store.Replay(ctx, "worker", DeadLetterFilter{}, openPublishers(ctx, client))
+	if err != nil || replayed != 1 || skipped != 1 {
+		t.Fatalf("replay = %d replayed, %d skipped, %v; want 1, 1", replayed, skipped, err)
+	}
+	if dl, _ := store.Get(ctx, "worker", bad.ID); dl == nil {
+		t.Error("unreadable entry was removed, want it kept")
+	}
+	if n, _ := client.LLen(ctx, "jobs:raw").Result(); n != 1 {
+		t.Errorf("queue length = %d, want 1", n)
+	}
+}
+
+func TestDeadLetterAttemptsAcrossReplays(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	store := NewDeadLetterStore(client, DeadLetterConfig{})
+	open := openPublishers(ctx, client)
+
+	// A retrier reports all failures of a round at once
+	dl := testDelivery(t, "jobs:raw", "1", 3).DeadLetter("worker", errors.New("timeout"))
+	dl.Attempts = 3
+	if err := store.DeadLetter(ctx, dl); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Replay(ctx, "worker", DeadLetterFilter{}, open); err != nil {
+		t.Fatal(err)
+	}
+
+	// The replayed job fails once more
+	again := testDelivery(t, "jobs:raw", "1", 1).DeadLetter("worker", errors.New("timeout"))
+	if err := store.DeadLetter(ctx, again); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get(ctx, "worker", "topcv:1")
+	if err != nil || got == nil {
+		t.Fatalf("get = %v, %v", got, err)
+	}
+	if got.Attempts != 4 {
+		t.Errorf("attempts after a replay = %d, want 4", got.Attempts)
+	}
+
+	// Purging forgets the count
+	if n, err := store.Purge(ctx, "worker", DeadLetterFilter{}); err != nil || n != 1 {
+		t.Fatalf("purge = %d, %v; want 1", n, err)
+	}
+	fresh := testDelivery(t, "jobs:raw", "1", 1).DeadLetter("worker", errors.New("timeout"))
+	if err := store.DeadLetter(ctx, fresh); err != nil {
+		t.Fatal(err)
+	}
+	if fresh.Attempts != 1 {
+		t.Errorf("attempts after a purge = %d, want 1", fresh.Attempts)
+	}
+}
+
+func TestDeadLetterMaxLen(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	store := NewDeadLetterStore(client, DeadLetterConfig{MaxLen: 2})
+
+	start := time.Now().Add(-time.Hour)
+	for i := 1; i <= 3; i++ {
+		dl := testDelivery(t, "jobs:raw", fmt.Sprint(i), 1).DeadLetter("worker", errors.New("timeout"))
+		dl.FailedAt = start.Add(time.Duration(i) * time.Minute)
+		if err := store.DeadLetter(ctx, dl); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, _ := store.Count(ctx, "worker"); n != 2 {
+		t.Errorf("count = %d, want 2", n)
+	}
+	entries, err := store.List(ctx, "worker", DeadLetterFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].ID != "topcv:3" || entries[1].ID != "topcv:2" {
+		t.Errorf("entries = %v, want the newest two, newest first", entries)
+	}
+	// The oldest entry goes with its attempt count
+	if n, _ := client.HLen(ctx, "dlq:worker").Result(); n != 2 {
+		t.Errorf("entries hash = %d, want 2", n)
+	}
+	if ok, _ := client.HExists(ctx, "dlq:worker:attempts", "topcv:1").Result(); ok {
+		t.Error("attempt count of the trimmed entry was kept")
+	}
+}
+
+func TestDeadLetterPurgeFiltered(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	store := NewDeadLetterStore(client, DeadLetterConfig{})
+
+	for _, cause := range []string{"index: mapping error", "normalize: bad salary"} {
+		dl := testDelivery(t, "jobs:raw", cause[:5], 1).DeadLetter("worker", errors.New(cause))
+		if err := store.DeadLetter(ctx, dl); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := store.Purge(ctx, "worker", DeadLetterFilter{Error: "INDEX:"})
+	if err != nil || n != 1 {
+		t.Fatalf("purge = %d, %v; want 1", n, err)
+	}
+	entries, err := store.List(ctx, "worker", DeadLetterFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Error != "normalize: bad salary" {
+		t.Errorf("entries after purge = %v, want the normalize failure only", entries)
+	}
+	if ok, _ := client.HExists(ctx, "dlq:worker:attempts", "topcv:index").Result(); ok {
+		t.Error("attempt count of the purged entry was kept")
+	}
+}
@@ -0,0 +1,212 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/domain"
+)
+
+// SchemaVersion is the envelope schema publishers write; consumers reject
+// newer ones (dead-lettered, replayable once they are upgraded)
+const SchemaVersion = 1
+
+// Envelope wraps every job on a queue with where it came from
+// Payloads without one (schema 0) are legacy bare RawJobs
+type Envelope struct {
+	Schema int `json:"schema"`
+	// ID identifies the message; it is kept when the message is requeued
+	ID string `json:"id"`
+	// RunID is the crawl run that produced the job
+	RunID    string   `json:"run_id,omitempty"`
+	Producer Producer `json:"producer"`
+	// Attempts counts deliveries of the message, starting at 1
+	Attempts   int       `json:"attempts"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	// TraceParent is the W3C trace context of the message
+	TraceParent string         `json:"traceparent,omitempty"`
+	Job         *domain.RawJob `json:"job"`
+}
+
+// Producer names the binary that published a message
+type Producer struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+var (
+	defaultProducer     Producer
+	defaultProducerOnce sync.Once
+)
+
+// DefaultProducer names the running binary, with the VCS revision (or module
+// version) it was built from
+func DefaultProducer() Producer {
+	defaultProducerOnce.Do(func() {
+		defaultProducer.Name = strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		defaultProducer.Version = info.Main.Version
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" && len(setting.Value) >= 12 {
+				defaultProducer.Version = setting.Value[:12]
+			}
+		}
+	})
+	return defaultProducer
+}
+
+// newEnvelope wraps a job for publishing, with the run and trace of ctx
+func newEnvelope(ctx context.Context, producer Producer, job *domain.RawJob) *Envelope {
+	meta := metaFrom(ctx)
+	return &Envelope{
+		Schema:      SchemaVersion,
+		ID:          newMessageID(),
+		RunID:       meta.runID,
+		Producer:    producer,
+		Attempts:    1,
+		EnqueuedAt:  time.Now().UTC(),
+		TraceParent: childTraceParent(meta.traceParent),
+		Job:         job,
+	}
+}
+
+// requeued returns a copy of the envelope for another delivery attempt;
+// legacy payloads get an envelope of their own
+func (e Envelope) requeued(producer Producer) *Envelope {
+	if e.Schema == 0 {
+		e.Schema = SchemaVersion
+		e.ID = newMessageID()
+		e.Producer = producer
+		e.TraceParent = childTraceParent("")
+	}
+	e.Attempts++
+	e.EnqueuedAt = time.Now().UTC()
+	return &e
+}
+
+// replayed returns a copy of the envelope for a replay from the dead-letter
+// store: the message keeps its ID but starts a new round of attempts
+func (e Envelope) replayed(producer Producer) *Envelope {
+	r := e.requeued(producer)
+	r.Attempts = 1
+	return r
+}
+
+// decodeEnvelope reads a queue payload, enveloped or a legacy bare RawJob
+func decodeEnvelope(payload []byte) (Envelope, error) {
+	var probe struct {
+		Schema int `json:"schema"`
+	}
+	if err := json.Unmarshal(payload, &probe); err != nil {
+		return Envelope{}, fmt.Errorf("unmarshal job: %w", err)
+	}
+
+	var env Envelope
+	switch {
+	case probe.Schema == 0:
+		env.Attempts = 1
+		if err := json.Unmarshal(payload, &env.Job); err != nil {
+			return Envelope{}, fmt.Errorf("unmarshal job: %w", err)
+		}
+	case probe.Schema > SchemaVersion:
+		return Envelope{}, fmt.Errorf("unsupported schema version %d (newest known %d)", probe.Schema, SchemaVersion)
+	default:
+		if err := json.Unmarshal(payload, &env); err != nil {
+			return Envelope{}, fmt.Errorf("unmarshal envelope: %w", err)
+		}
+	}
+	if env.Job == nil {
+		return Envelope{}, fmt.Errorf("unmarshal job: empty payload")
+	}
+	return env, nil
+}
+
+// messageMeta is the message metadata carried by a context
+type messageMeta struct {
+	runID       string
+	traceParent string
+	willRetry   bool
+}
+
+type metaKey struct{}
+
+func metaFrom(ctx context.Context) messageMeta {
+	meta, _ := ctx.Value(metaKey{}).(messageMeta)
+	return meta
+}
+
+// WithRunID tags the jobs published with ctx with a crawl run ID
+func WithRunID(ctx context.Context, runID string) context.Context {
+	meta := metaFrom(ctx)
+	meta.runID = runID
+	return context.WithValue(ctx, metaKey{}, meta)
+}
+
+// RunID returns the crawl run ID carried by ctx
+func RunID(ctx context.Context) string {
+	return metaFrom(ctx).runID
+}
+
+// WithTraceParent makes the jobs published with ctx children of a W3C trace context
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	meta := metaFrom(ctx)
+	meta.traceParent = traceParent
+	return context.WithValue(ctx, metaKey{}, meta)
+}
+
+// TraceParent returns the W3C trace context carried by ctx
+func TraceParent(ctx context.Context) string {
+	return metaFrom(ctx).traceParent
+}
+
+// WillRetry reports whether an error returned by the handler running with
+// ctx gets the job another attempt later; handlers can then fail on transient
+// errors instead of passing on partial results. It is false on the last
+// attempt, where a failure dead-letters the job
+func WillRetry(ctx context.Context) bool {
+	return metaFrom(ctx).willRetry
+}
+
+// withEnvelope carries a consumed message's run and trace to its handler,
+// so the jobs it publishes stay in the same run and trace
+func withEnvelope(ctx context.Context, env Envelope, willRetry bool) context.Context {
+	return context.WithValue(ctx, metaKey{}, messageMeta{runID: env.RunID, traceParent: env.TraceParent, willRetry: willRetry})
+}
+
+// NewTraceParent starts a new sampled W3C trace context
+func NewTraceParent() string {
+	return childTraceParent("")
+}
+
+// childTraceParent returns a traceparent with a new span ID in the trace of
+// parent, or in a new trace when parent is not a valid traceparent
+func childTraceParent(parent string) string {
+	parts := strings.Split(parent, "-")
+	if len(parts) == 4 && len(parts[1]) == 32 && len(parts[3]) == 2 {
+		return "00-" + parts[1] + "-" + randomHex(8) + "-" + parts[3]
+	}
+	return "00-" + randomHex(16) + "-" + randomHex(8) + "-01"
+}
+
+// newMessageID returns a time-ordered message ID
+func newMessageID() string {
+	return fmt.Sprintf("%x-%s", time.Now().UnixMilli(), randomHex(8))
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
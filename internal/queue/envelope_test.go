@@ -0,0 +1,101 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/project-tktt/go-crawler/internal/domain"
+)
+
+func TestDecodeEnvelope(t *testing.T) {
+	job := &domain.RawJob{ID: "42", Source: "topcv", URL: "https://www.topcv.vn/viec-lam/x/42.html"}
+
+	ctx := WithRunID(context.Background(), "topcv-run")
+	env := newEnvelope(ctx, Producer{Name: "crawler", Version: "abc123"}, job)
+	enveloped, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := json.Marshal(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		want    Envelope
+		wantErr string
+	}{
+		{
+			name:    "v1 envelope round-trip",
+			payload: string(enveloped),
+			want:    *env,
+		},
+		{
+			name:    "legacy bare RawJob",
+			payload: string(legacy),
+			want:    Envelope{Schema: 0, Attempts: 1, Job: job},
+		},
+		{
+			name:    "newer schema",
+			payload: `{"schema":2,"job":{"id":"42"}}`,
+			wantErr: "unsupported schema version 2",
+		},
+		{
+			name:    "not json",
+			payload: `{"schema":`,
+			wantErr: "unmarshal job",
+		},
+		{
+			name:    "envelope without job",
+			payload: `{"schema":1,"id":"m1","attempts":1}`,
+			wantErr: "empty payload",
+		},
+		{
+			name:    "envelope with malformed job",
+			payload: `{"schema":1,"id":"m1","job":"42"}`,
+			wantErr: "unmarshal envelope",
+		},
+		{
+			name:    "legacy null",
+			payload: `null`,
+			wantErr: "empty payload",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeEnvelope([]byte(tt.payload))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEnvelope(t, got, tt.want)
+		})
+	}
+}
+
+// assertEnvelope compares envelopes by their JSON form, so times compare
+// without their monotonic clock reading
+func assertEnvelope(t *testing.T, got, want Envelope) {
+	t.Helper()
+	g, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(g) != string(w) {
+		t.Errorf("envelope = %s\nwant %s", g, w)
+	}
+}
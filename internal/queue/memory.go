@@ -0,0 +1,149 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/domain"
+)
+
+// MemoryQueue is an in-process queue on a buffered channel, both a Publisher
+// and a Consumer, so a pipeline can run in one process or in a test without
+// Redis. Payloads are JSON-encoded as on Redis, so stages never share a
+// *RawJob, and batching/timeouts match RedisConsumer. Jobs are not
+// redelivered: Ack is a no-op
+type MemoryQueue struct {
+	name     string
+	items    chan []byte
+	timeout  time.Duration
+	producer Producer
+	failures failures
+}
+
+// NewMemoryQueue creates an in-process queue holding up to capacity jobs
+// (default 1000); publishers block while it is full
+func NewMemoryQueue(name string, capacity int, timeout time.Duration) *MemoryQueue {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	return &MemoryQueue{
+		name:     name,
+		items:    make(chan []byte, capacity),
+		timeout:  timeout,
+		producer: DefaultProducer(),
+	}
+}
+
+// WithDeadLetters dead-letters malformed jobs and jobs the Run handler fails
+// on as stage; without it they are logged and dropped
+func (q *MemoryQueue) WithDeadLetters(sink DeadLetterer, stage string) *MemoryQueue {
+	q.failures.sink, q.failures.stage = sink, stage
+	return q
+}
+
+// WithRetries schedules jobs the Run handler fails on for a delayed retry,
+// dead-lettered once out of attempts; a NewMemoryRetrier targeting q keeps
+// retries in process
+func (q *MemoryQueue) WithRetries(r *Retrier) *MemoryQueue {
+	q.failures.retrier = r
+	return q
+}
+
+// Publish pushes a single job to the queue
+func (q *MemoryQueue) Publish(ctx context.Context, job *domain.RawJob) error {
+	return q.PublishEnvelope(ctx, newEnvelope(ctx, q.producer, job))
+}
+
+// PublishEnvelope pushes an enveloped job to the queue
+func (q *MemoryQueue) PublishEnvelope(ctx context.Context, env *Envelope) error {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("marshal job: %w", err)
+	}
+	return q.push(ctx, data)
+}
+
+// PublishBatch pushes multiple jobs to the queue
+func (q *MemoryQueue) PublishBatch(ctx context.Context, jobs []*domain.RawJob) error {
+	for _, job := range jobs {
+		if err := q.Publish(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishRaw pushes arbitrary data to the queue as is (for validation/debugging)
+func (q *MemoryQueue) PublishRaw(ctx context.Context, data any) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal data: %w", err)
+	}
+	return q.push(ctx, jsonData)
+}
+
+// QueueLength returns the current queue length
+func (q *MemoryQueue) QueueLength(ctx context.Context) (int64, error) {
+	return int64(len(q.items)), nil
+}
+
+// ConsumeBatch waits up to the timeout for the first job, then takes up to
+// maxBatch jobs that are already queued
+func (q *MemoryQueue) ConsumeBatch(ctx context.Context, maxBatch int) ([]*Delivery, error) {
+	deliveries := make([]*Delivery, 0, maxBatch)
+
+	timer := time.NewTimer(q.timeout)
+	defer timer.Stop()
+
+	var payload []byte
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return deliveries, nil // Timeout, no jobs
+	case payload = <-q.items:
+	}
+
+	for {
+		if d, err := newDelivery(q.name, string(payload), ""); err == nil {
+			deliveries = append(deliveries, d)
+		} else {
+			log.Printf("[Queue] %s: dropping malformed item: %v", q.name, err)
+			q.failures.send(ctx, d, err)
+		}
+		if len(deliveries) >= maxBatch {
+			return deliveries, nil
+		}
+
+		select {
+		case payload = <-q.items:
+		default:
+			return deliveries, nil // No more jobs
+		}
+	}
+}
+
+// Ack is a no-op: consumed jobs are already gone from the channel
+func (q *MemoryQueue) Ack(ctx context.Context, deliveries ...*Delivery) error {
+	return nil
+}
+
+// Run starts a continuous consumer loop
+func (q *MemoryQueue) Run(ctx context.Context, handler Handler) error {
+	return run(ctx, q, q.name, q.failures, handler)
+}
+
+func (q *MemoryQueue) push(ctx context.Context, data []byte) error {
+	select {
+	case q.items <- data:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
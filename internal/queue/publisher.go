@@ -9,50 +9,109 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-// Publisher pushes jobs to Redis queue
-type Publisher struct {
+// Publisher pushes jobs onto a queue
+// Implemented by RedisPublisher and MemoryQueue
+type Publisher interface {
+	// Publish wraps a job in an Envelope, tagged with the run and trace of ctx
+	Publish(ctx context.Context, job *domain.RawJob) error
+	PublishBatch(ctx context.Context, jobs []*domain.RawJob) error
+	// PublishEnvelope pushes an already wrapped job (requeues and replays)
+	PublishEnvelope(ctx context.Context, env *Envelope) error
+	// PublishRaw pushes arbitrary JSON data as is (for validation/debugging queues)
+	PublishRaw(ctx context.Context, data any) error
+	QueueLength(ctx context.Context) (int64, error)
+}
+
+// RedisPublisher pushes jobs to Redis queue (a list, or a stream for
+// publishers created with NewStreamPublisher)
+type RedisPublisher struct {
 	client    *redis.Client
 	queueName string
+	stream    bool
+	maxLen    int64 // Approximate stream length cap (0 = untrimmed)
+	producer  Producer
 }
 
 // NewPublisher creates a new queue publisher
-func NewPublisher(client *redis.Client, queueName string) *Publisher {
+func NewPublisher(client *redis.Client, queueName string) *RedisPublisher {
 	if queueName == "" {
 		queueName = "jobs:raw"
 	}
-	return &Publisher{
+	return &RedisPublisher{
 		client:    client,
 		queueName: queueName,
+		producer:  DefaultProducer(),
 	}
 }
 
-// Publish pushes a single job to the queue
-func (p *Publisher) Publish(ctx context.Context, job *domain.RawJob) error {
-	data, err := json.Marshal(job)
+// NewStreamPublisher creates a publisher that appends to a Redis stream with
+// XADD, trimming it to about maxLen entries (0 = never trim)
+// Every consumer group of the stream receives every job
+func NewStreamPublisher(client *redis.Client, stream string, maxLen int64) *RedisPublisher {
+	p := NewPublisher(client, stream)
+	p.stream = true
+	p.maxLen = maxLen
+	return p
+}
+
+// OpenPublisher creates a publisher for an existing queue: a stream
+// publisher trimming to about maxLen entries when the key is a stream, a
+// list publisher otherwise. A stream read by several consumer groups is
+// refused, as every group would receive the jobs again; publish to a
+// group's own stream (StreamConsumer.GroupStream) instead
+func OpenPublisher(ctx context.Context, client *redis.Client, queueName string, maxLen int64) (*RedisPublisher, error) {
+	kind, err := client.Type(ctx, queueName).Result()
 	if err != nil {
-		return fmt.Errorf("marshal job: %w", err)
+		return nil, fmt.Errorf("type %s: %w", queueName, err)
+	}
+	if kind != "stream" {
+		return NewPublisher(client, queueName), nil
 	}
+	groups, err := client.XInfoGroups(ctx, queueName).Result()
+	if err != nil {
+		return nil, fmt.Errorf("groups of %s: %w", queueName, err)
+	}
+	if len(groups) > 1 {
+		return nil, fmt.Errorf("stream %s is shared by %d consumer groups", queueName, len(groups))
+	}
+	return NewStreamPublisher(client, queueName, maxLen), nil
+}
 
-	if err := p.client.LPush(ctx, p.queueName, data).Err(); err != nil {
-		return fmt.Errorf("lpush: %w", err)
+// WithProducer overrides the producer written in envelopes (default: the
+// binary name and build version)
+func (p *RedisPublisher) WithProducer(producer Producer) *RedisPublisher {
+	p.producer = producer
+	return p
+}
+
+// Publish pushes a single job to the queue
+func (p *RedisPublisher) Publish(ctx context.Context, job *domain.RawJob) error {
+	return p.PublishEnvelope(ctx, newEnvelope(ctx, p.producer, job))
+}
+
+// PublishEnvelope pushes an enveloped job to the queue
+func (p *RedisPublisher) PublishEnvelope(ctx context.Context, env *Envelope) error {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("marshal job: %w", err)
 	}
 
-	return nil
+	return p.push(ctx, p.client, data)
 }
 
 // PublishBatch pushes multiple jobs to the queue
-func (p *Publisher) PublishBatch(ctx context.Context, jobs []*domain.RawJob) error {
+func (p *RedisPublisher) PublishBatch(ctx context.Context, jobs []*domain.RawJob) error {
 	if len(jobs) == 0 {
 		return nil
 	}
 
 	pipe := p.client.Pipeline()
 	for _, job := range jobs {
-		data, err := json.Marshal(job)
+		data, err := json.Marshal(newEnvelope(ctx, p.producer, job))
 		if err != nil {
 			return fmt.Errorf("marshal job: %w", err)
 		}
-		pipe.LPush(ctx, p.queueName, data)
+		p.push(ctx, pipe, data)
 	}
 
 	_, err := pipe.Exec(ctx)
@@ -64,20 +123,41 @@ func (p *Publisher) PublishBatch(ctx context.Context, jobs []*domain.RawJob) err
 }
 
 // QueueLength returns the current queue length
-func (p *Publisher) QueueLength(ctx context.Context) (int64, error) {
+// For a stream this is the number of retained entries, read or not
+func (p *RedisPublisher) QueueLength(ctx context.Context) (int64, error) {
+	if p.stream {
+		return p.client.XLen(ctx, p.queueName).Result()
+	}
 	return p.client.LLen(ctx, p.queueName).Result()
 }
 
-// PublishRaw pushes arbitrary data to the queue (for validation/debugging)
-func (p *Publisher) PublishRaw(ctx context.Context, data any) error {
+// PublishRaw pushes arbitrary data to the queue as is (for validation/debugging)
+func (p *RedisPublisher) PublishRaw(ctx context.Context, data any) error {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return fmt.Errorf("marshal data: %w", err)
 	}
 
-	if err := p.client.LPush(ctx, p.queueName, jsonData).Err(); err != nil {
+	return p.push(ctx, p.client, jsonData)
+}
+
+// push sends an LPUSH, or an XADD for streams, through cmd (client or pipeline)
+// A pipelined push reports its error from Exec
+func (p *RedisPublisher) push(ctx context.Context, cmd redis.Cmdable, data []byte) error {
+	if p.stream {
+		err := cmd.XAdd(ctx, &redis.XAddArgs{
+			Stream: p.queueName,
+			MaxLen: p.maxLen,
+			Approx: true,
+			Values: []any{streamField, data},
+		}).Err()
+		if err != nil {
+			return fmt.Errorf("xadd: %w", err)
+		}
+		return nil
+	}
+	if err := cmd.LPush(ctx, p.queueName, data).Err(); err != nil {
 		return fmt.Errorf("lpush: %w", err)
 	}
-
 	return nil
 }
@@ -0,0 +1,269 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeMore moves up to ARGV[2] more items into the processing list and
+// records a visibility deadline for them and for ARGV[3] (already moved by BLMOVE)
+// KEYS: queue, processing list, deadlines zset
+var takeMore = redis.NewScript(`
+local deadline = tonumber(ARGV[1])
+local items = {}
+redis.call("ZADD", KEYS[3], deadline, ARGV[3])
+for i = 1, tonumber(ARGV[2]) do
+	local item = redis.call("LMOVE", KEYS[1], KEYS[2], "RIGHT", "LEFT")
+	if not item then
+		break
+	end
+	redis.call("ZADD", KEYS[3], deadline, item)
+	items[#items + 1] = item
+end
+return items
+`)
+
+// ack drops handled items from the processing list
+// KEYS: processing list, deadlines zset; ARGV: payloads
+var ack = redis.NewScript(`
+for _, item in ipairs(ARGV) do
+	redis.call("LREM", KEYS[1], 1, item)
+	if not redis.call("LPOS", KEYS[1], item) then
+		redis.call("ZREM", KEYS[2], item)
+	end
+end
+return #ARGV
+`)
+
+// reap returns items whose deadline passed to the consuming end of the queue
+// Items without a deadline (taken right before a crash) get one now
+// KEYS: queue, processing list, deadlines zset; ARGV: now, deadline for new items, requeue all
+var reap = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local all = ARGV[3] == "1"
+local moved = 0
+for _, item in ipairs(redis.call("LRANGE", KEYS[2], 0, -1)) do
+	local deadline = tonumber(redis.call("ZSCORE", KEYS[3], item))
+	if all or (deadline and deadline <= now) then
+		redis.call("LREM", KEYS[2], 1, item)
+		redis.call("RPUSH", KEYS[1], item)
+		if not redis.call("LPOS", KEYS[2], item) then
+			redis.call("ZREM", KEYS[3], item)
+		end
+		moved = moved + 1
+	elseif not deadline then
+		redis.call("ZADD", KEYS[3], tonumber(ARGV[2]), item)
+	end
+end
+return moved
+`)
+
+// ReliableConfig holds reliable consumer options
+type ReliableConfig struct {
+	// Name identifies the consumer's processing list (default hostname)
+	Name string
+	// Timeout is how long a consume blocks waiting for the first item (default 5s)
+	Timeout time.Duration
+	// VisibilityTimeout is how long an item may stay in flight before the
+	// reaper returns it to the queue (default 5m)
+	VisibilityTimeout time.Duration
+	// ReapInterval is how often RunReaper scans for stale items (default 1m)
+	ReapInterval time.Duration
+}
+
+// ReliableConsumer takes jobs with LMOVE into a per-consumer processing list,
+// so a job popped by a crashed or failed consumer is not lost: it stays in
+// flight until acknowledged, or until the reaper puts it back on the queue
+type ReliableConsumer struct {
+	client    *redis.Client
+	queueName string
+	config    ReliableConfig
+	failures  failures
+}
+
+// NewReliableConsumer creates a reliable queue consumer
+func NewReliableConsumer(client *redis.Client, queueName string, cfg ReliableConfig) *ReliableConsumer {
+	if queueName == "" {
+		queueName = "jobs:raw"
+	}
+	if cfg.Name == "" {
+		cfg.Name, _ = os.Hostname()
+		if cfg.Name == "" {
+			cfg.Name = fmt.Sprintf("pid-%d", os.Getpid())
+		}
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.VisibilityTimeout <= 0 {
+		cfg.VisibilityTimeout = 5 * time.Minute
+	}
+	if cfg.ReapInterval <= 0 {
+		cfg.ReapInterval = time.Minute
+	}
+	return &ReliableConsumer{
+		client:    client,
+		queueName: queueName,
+		config:    cfg,
+	}
+}
+
+// WithDeadLetters dead-letters malformed jobs and jobs the Run handler fails
+// on as stage, instead of dropping or redelivering them
+func (c *ReliableConsumer) WithDeadLetters(sink DeadLetterer, stage string) *ReliableConsumer {
+	c.failures.sink, c.failures.stage = sink, stage
+	return c
+}
+
+// WithRetries schedules jobs the Run handler fails on for a delayed retry,
+// dead-lettered once out of attempts
+func (c *ReliableConsumer) WithRetries(r *Retrier) *ReliableConsumer {
+	c.failures.retrier = r
+	return c
+}
+
+// ConsumeBatch takes up to maxBatch jobs into this consumer's processing list
+// Uses BLMOVE to block-wait for the first item, then LMOVE for the rest
+// Malformed items are dead-lettered, dropped from the processing list and skipped
+func (c *ReliableConsumer) ConsumeBatch(ctx context.Context, maxBatch int) ([]*Delivery, error) {
+	processing := c.processingKey(c.config.Name)
+
+	first, err := c.client.BLMove(ctx, c.queueName, processing, "RIGHT", "LEFT", c.config.Timeout).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil // Timeout, no jobs
+		}
+		return nil, fmt.Errorf("blmove: %w", err)
+	}
+
+	deadline := time.Now().Add(c.config.VisibilityTimeout).UnixMilli()
+	keys := []string{c.queueName, processing, c.deadlinesKey(c.config.Name)}
+	rest, err := takeMore.Run(ctx, c.client, keys, deadline, maxBatch-1, first).StringSlice()
+	if err != nil {
+		// The first item is in flight without a deadline; the reaper assigns one
+		return nil, fmt.Errorf("lmove: %w", err)
+	}
+
+	deliveries := make([]*Delivery, 0, len(rest)+1)
+	var malformed []*Delivery
+	for _, payload := range append([]string{first}, rest...) {
+		d, err := newDelivery(c.queueName, payload, "")
+		if err != nil {
+			log.Printf("[Queue] %s: dropping malformed item: %v", c.queueName, err)
+			c.failures.send(ctx, d, err)
+			malformed = append(malformed, d)
+			continue
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := c.Ack(ctx, malformed...); err != nil {
+		log.Printf("[Queue] %s: %v", c.queueName, err)
+	}
+	return deliveries, nil
+}
+
+// Ack removes handled jobs from the processing list
+func (c *ReliableConsumer) Ack(ctx context.Context, deliveries ...*Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	payloads := make([]any, len(deliveries))
+	for i, d := range deliveries {
+		payloads[i] = d.payload
+	}
+	keys := []string{c.processingKey(c.config.Name), c.deadlinesKey(c.config.Name)}
+	if err := ack.Run(ctx, c.client, keys, payloads...).Err(); err != nil {
+		return fmt.Errorf("ack: %w", err)
+	}
+	return nil
+}
+
+// Run starts a continuous consumer loop; jobs are acked once handler
+// succeeds, failed ones are dead-lettered, or go back to the queue after
+// VisibilityTimeout without a dead-letter store
+func (c *ReliableConsumer) Run(ctx context.Context, handler Handler) error {
+	return run(ctx, c, c.queueName, c.failures, handler)
+}
+
+// Recover returns everything left in this consumer's processing list to the
+// queue; call it on startup, before consuming, to requeue the jobs a previous
+// process with the same Name had in flight
+func (c *ReliableConsumer) Recover(ctx context.Context) (int, error) {
+	return c.reapList(ctx, c.config.Name, true)
+}
+
+// Reap returns in-flight jobs older than VisibilityTimeout to the queue,
+// across every consumer of the queue
+func (c *ReliableConsumer) Reap(ctx context.Context) (int, error) {
+	prefix := c.processingKey("")
+	total := 0
+	iter := c.client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		n, err := c.reapList(ctx, strings.TrimPrefix(iter.Val(), prefix), false)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	if err := iter.Err(); err != nil {
+		return total, fmt.Errorf("scan processing lists: %w", err)
+	}
+	return total, nil
+}
+
+// RunReaper calls Reap every ReapInterval until ctx is cancelled
+func (c *ReliableConsumer) RunReaper(ctx context.Context) {
+	ticker := time.NewTicker(c.config.ReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := c.Reap(ctx)
+		if err != nil {
+			log.Printf("[Queue] %s: reap error: %v", c.queueName, err)
+		}
+		if n > 0 {
+			log.Printf("[Queue] %s: returned %d stale in-flight jobs to the queue", c.queueName, n)
+		}
+	}
+}
+
+// InFlight returns the number of jobs in this consumer's processing list
+func (c *ReliableConsumer) InFlight(ctx context.Context) (int64, error) {
+	return c.client.LLen(ctx, c.processingKey(c.config.Name)).Result()
+}
+
+func (c *ReliableConsumer) reapList(ctx context.Context, name string, all bool) (int, error) {
+	now := time.Now()
+	flag := "0"
+	if all {
+		flag = "1"
+	}
+	keys := []string{c.queueName, c.processingKey(name), c.deadlinesKey(name)}
+	n, err := reap.Run(ctx, c.client, keys, now.UnixMilli(), now.Add(c.config.VisibilityTimeout).UnixMilli(), flag).Int()
+	if err != nil {
+		return 0, fmt.Errorf("reap %s: %w", name, err)
+	}
+	return n, nil
+}
+
+// processingKey is the in-flight list of a consumer: <queue>:processing:<name>
+func (c *ReliableConsumer) processingKey(name string) string {
+	return c.queueName + ":processing:" + name
+}
+
+// deadlinesKey holds visibility deadlines of a consumer's in-flight items
+func (c *ReliableConsumer) deadlinesKey(name string) string {
+	return c.queueName + ":inflight:" + name
+}
@@ -0,0 +1,178 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestClient starts a miniredis server for the test
+func newTestClient(t *testing.T) *redis.Client {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// publishJobs publishes jobs with the given IDs to a list queue
+func publishJobs(t *testing.T, client *redis.Client, queueName string, ids ...string) {
+	t.Helper()
+	pub := NewPublisher(client, queueName)
+	for _, id := range ids {
+		if err := pub.Publish(context.Background(), &domain.RawJob{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func deliveryIDs(deliveries []*Delivery) []string {
+	ids := make([]string, len(deliveries))
+	for i, d := range deliveries {
+		ids[i] = d.Job.ID
+	}
+	return ids
+}
+
+func TestReliableReapAfterVisibilityTimeout(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	publishJobs(t, client, "jobs:raw", "1", "2")
+
+	cfg := ReliableConfig{Name: "a", Timeout: time.Second, VisibilityTimeout: 50 * time.Millisecond}
+	crashed := NewReliableConsumer(client, "jobs:raw", cfg)
+	deliveries, err := crashed.ConsumeBatch(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 2 {
+		t.Fatalf("consumed %v, want 2 jobs", deliveryIDs(deliveries))
+	}
+
+	// Another consumer reaps the crashed one's processing list
+	reaper := NewReliableConsumer(client, "jobs:raw", ReliableConfig{Name: "b", Timeout: time.Second})
+	if n, err := reaper.Reap(ctx); err != nil || n != 0 {
+		t.Fatalf("reap before the visibility timeout = %d, %v; want 0", n, err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if n, err := reaper.Reap(ctx); err != nil || n != 2 {
+		t.Fatalf("reap after the visibility timeout = %d, %v; want 2", n, err)
+	}
+	if n, _ := crashed.InFlight(ctx); n != 0 {
+		t.Errorf("in flight after reap = %d, want 0", n)
+	}
+	if keys, _ := client.Keys(ctx, "jobs:raw:inflight:*").Result(); len(keys) != 0 {
+		t.Errorf("deadlines left after reap: %v", keys)
+	}
+
+	redelivered, err := reaper.ConsumeBatch(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := deliveryIDs(redelivered); len(ids) != 2 || ids[0] != "1" || ids[1] != "2" {
+		t.Errorf("redelivered %v, want [1 2] in publish order", ids)
+	}
+}
+
+func TestReliableReapItemWithoutDeadline(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	publishJobs(t, client, "jobs:raw", "1")
+
+	// A consumer crashed between BLMOVE and recording the deadline
+	if err := client.LMove(ctx, "jobs:raw", "jobs:raw:processing:a", "RIGHT", "LEFT").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewReliableConsumer(client, "jobs:raw", ReliableConfig{Name: "b", VisibilityTimeout: 50 * time.Millisecond})
+	if n, err := c.Reap(ctx); err != nil || n != 0 {
+		t.Fatalf("first reap = %d, %v; want 0 (deadline assigned)", n, err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n, err := c.Reap(ctx); err != nil || n != 1 {
+		t.Fatalf("reap after the visibility timeout = %d, %v; want 1", n, err)
+	}
+	if n, _ := client.LLen(ctx, "jobs:raw").Result(); n != 1 {
+		t.Errorf("queue length = %d, want 1", n)
+	}
+}
+
+func TestReliableAckRemovesOnlyAckedItems(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	publishJobs(t, client, "jobs:raw", "1", "2", "3")
+
+	c := NewReliableConsumer(client, "jobs:raw", ReliableConfig{Name: "a", Timeout: time.Second})
+	deliveries, err := c.ConsumeBatch(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 3 {
+		t.Fatalf("consumed %v, want 3 jobs", deliveryIDs(deliveries))
+	}
+
+	if err := c.Ack(ctx, deliveries[0], deliveries[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	left, err := client.LRange(ctx, "jobs:raw:processing:a", 0, -1).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 1 || left[0] != deliveries[1].payload {
+		t.Errorf("processing list = %v, want only job 2", left)
+	}
+	deadlines, err := client.ZRange(ctx, "jobs:raw:inflight:a", 0, -1).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deadlines) != 1 || deadlines[0] != deliveries[1].payload {
+		t.Errorf("deadlines = %v, want only job 2", deadlines)
+	}
+
+	// Recover requeues what was not acked
+	if n, err := c.Recover(ctx); err != nil || n != 1 {
+		t.Fatalf("recover = %d, %v; want 1", n, err)
+	}
+	if n, _ := client.LLen(ctx, "jobs:raw").Result(); n != 1 {
+		t.Errorf("queue length = %d, want 1", n)
+	}
+}
+
+func TestReliableAckKeepsDuplicatePayload(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+
+	// The same payload queued twice is in flight twice; one ack drops one copy
+	env := newEnvelope(ctx, DefaultProducer(), &domain.RawJob{ID: "1"})
+	pub := NewPublisher(client, "jobs:raw")
+	for range 2 {
+		if err := pub.PublishEnvelope(ctx, env); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := NewReliableConsumer(client, "jobs:raw", ReliableConfig{Name: "a", Timeout: time.Second})
+	deliveries, err := c.ConsumeBatch(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 2 {
+		t.Fatalf("consumed %d jobs, want 2", len(deliveries))
+	}
+
+	if err := c.Ack(ctx, deliveries[0]); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := c.InFlight(ctx); n != 1 {
+		t.Errorf("in flight = %d, want 1", n)
+	}
+	if n, _ := client.ZCard(ctx, "jobs:raw:inflight:a").Result(); n != 1 {
+		t.Errorf("deadlines = %d, want the remaining copy's", n)
+	}
+}
@@ -0,0 +1,325 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// moveDue pushes scheduled payloads whose time has come onto a Redis queue
+// KEYS: schedule zset, queue; ARGV: now, limit, "1" for a stream, stream MAXLEN, stream field
+var moveDue = redis.NewScript(`
+local due = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, tonumber(ARGV[2]))
+for _, payload in ipairs(due) do
+	redis.call("ZREM", KEYS[1], payload)
+	if ARGV[3] ~= "1" then
+		redis.call("LPUSH", KEYS[2], payload)
+	elseif tonumber(ARGV[4]) > 0 then
+		redis.call("XADD", KEYS[2], "MAXLEN", "~", ARGV[4], "*", ARGV[5], payload)
+	else
+		redis.call("XADD", KEYS[2], "*", ARGV[5], payload)
+	end
+end
+return #due
+`)
+
+// takeDue removes and returns scheduled payloads whose time has come
+// KEYS: schedule zset; ARGV: now, limit
+var takeDue = redis.NewScript(`
+local due = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, tonumber(ARGV[2]))
+for _, payload in ipairs(due) do
+	redis.call("ZREM", KEYS[1], payload)
+end
+return due
+`)
+
+// RetryConfig holds delayed retry options
+type RetryConfig struct {
+	// Stage names the schedule (retry:<stage>) and the dead letters
+	// (default: the target's queue name)
+	Stage string
+	// MaxAttempts is the number of deliveries before a job is dead-lettered
+	// (default 5; 1 dead-letters on the first failure)
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry, doubled per attempt (default 30s)
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts (default 1h)
+	MaxDelay time.Duration
+	// Interval is how often RunMover looks for due jobs (default 1s)
+	Interval time.Duration
+	// BatchSize caps the jobs moved per Move call (default 100)
+	BatchSize int
+	// Prefix of the Redis schedule key (default "retry")
+	Prefix string
+}
+
+// Retrier schedules failed jobs for another attempt, ordered by their next
+// attempt time (a Redis sorted set, or process memory for in-memory
+// pipelines); the mover pushes due jobs back to the target publisher with one
+// more attempt in their envelope. Jobs that used up MaxAttempts go to the
+// dead-letter store instead
+type Retrier struct {
+	schedule    retrySchedule
+	target      Publisher
+	deadLetters DeadLetterer
+	config      RetryConfig
+}
+
+// NewRetrier creates a retrier keeping its schedule in Redis (retry:<stage>)
+// and putting due jobs back on target's queue
+// dl receives jobs out of attempts (nil = dropped with a log line)
+func NewRetrier(client *redis.Client, target Publisher, dl DeadLetterer, cfg RetryConfig) *Retrier {
+	r := newRetrier(target, dl, cfg)
+	r.schedule = &redisSchedule{client: client, key: r.config.Prefix + ":" + r.config.Stage}
+	return r
+}
+
+// NewMemoryRetrier creates a retrier keeping its schedule in process memory,
+// for pipelines running on MemoryQueue; scheduled jobs are lost on exit
+func NewMemoryRetrier(target Publisher, dl DeadLetterer, cfg RetryConfig) *Retrier {
+	r := newRetrier(target, dl, cfg)
+	r.schedule = &memorySchedule{}
+	return r
+}
+
+func newRetrier(target Publisher, dl DeadLetterer, cfg RetryConfig) *Retrier {
+	if cfg.Stage == "" {
+		cfg.Stage = queueName(target)
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = 30 * time.Second
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = time.Hour
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "retry"
+	}
+	return &Retrier{
+		target:      target,
+		deadLetters: dl,
+		config:      cfg,
+	}
+}
+
+// Retry schedules another attempt of a failed delivery after a backoff, or
+// dead-letters it once it has been delivered MaxAttempts times
+// The caller acks the delivery once Retry succeeds
+func (r *Retrier) Retry(ctx context.Context, d *Delivery, cause error) error {
+	attempts := max(d.Envelope.Attempts, 1)
+	if !r.willRetry(d) {
+		log.Printf("[Retry] %s: %s failed %d times, giving up: %v", r.config.Stage, d.Job.ID, attempts, cause)
+		if r.deadLetters == nil {
+			return nil
+		}
+		// Report every failure of this round; replays start a new one
+		dl := d.DeadLetter(r.config.Stage, cause)
+		dl.Attempts = attempts
+		return r.deadLetters.DeadLetter(ctx, dl)
+	}
+
+	data, err := json.Marshal(d.Envelope.requeued(DefaultProducer()))
+	if err != nil {
+		return fmt.Errorf("marshal job: %w", err)
+	}
+	delay := r.backoff(attempts)
+	if err := r.schedule.add(ctx, time.Now().Add(delay), data); err != nil {
+		return fmt.Errorf("schedule retry: %w", err)
+	}
+	log.Printf("[Retry] %s: %s failed (attempt %d/%d), retrying in %s: %v", r.config.Stage, d.Job.ID, attempts, r.config.MaxAttempts, delay.Round(time.Second), cause)
+	return nil
+}
+
+// Move pushes up to BatchSize due jobs back to the target
+func (r *Retrier) Move(ctx context.Context) (int, error) {
+	n, err := r.schedule.move(ctx, r.target, time.Now(), r.config.BatchSize)
+	if err != nil {
+		return n, fmt.Errorf("move due retries: %w", err)
+	}
+	return n, nil
+}
+
+// RunMover moves due jobs every Interval until ctx is cancelled
+func (r *Retrier) RunMover(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Keep moving while full batches come back
+		for {
+			n, err := r.Move(ctx)
+			if err != nil {
+				log.Printf("[Retry] %s: %v", r.config.Stage, err)
+				break
+			}
+			if n > 0 {
+				log.Printf("[Retry] %s: returned %d jobs to %s", r.config.Stage, n, queueName(r.target))
+			}
+			if n < r.config.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+// Scheduled returns the number of jobs waiting for their next attempt
+func (r *Retrier) Scheduled(ctx context.Context) (int64, error) {
+	return r.schedule.size(ctx)
+}
+
+// willRetry reports whether a failure of d gets another attempt
+func (r *Retrier) willRetry(d *Delivery) bool {
+	return max(d.Envelope.Attempts, 1) < r.config.MaxAttempts
+}
+
+// backoff returns the wait after the given attempt: BaseDelay doubled per
+// attempt, capped at MaxDelay, with jitter over its upper half
+func (r *Retrier) backoff(attempt int) time.Duration {
+	d := r.config.BaseDelay << min(attempt-1, 16)
+	if d <= 0 || d > r.config.MaxDelay {
+		d = r.config.MaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retrySchedule holds requeued payloads until their next attempt is due
+type retrySchedule interface {
+	add(ctx context.Context, due time.Time, payload []byte) error
+	// move pushes up to limit payloads due at now to target
+	move(ctx context.Context, target Publisher, now time.Time, limit int) (int, error)
+	size(ctx context.Context) (int64, error)
+}
+
+// redisSchedule is a sorted set of payloads scored by due time
+type redisSchedule struct {
+	client *redis.Client
+	key    string
+}
+
+func (s *redisSchedule) add(ctx context.Context, due time.Time, payload []byte) error {
+	return s.client.ZAdd(ctx, s.key, redis.Z{Score: float64(due.UnixMilli()), Member: payload}).Err()
+}
+
+// move hands due payloads to a Redis queue in one script, so a crash cannot
+// lose or duplicate them; other targets get them through PublishEnvelope
+func (s *redisSchedule) move(ctx context.Context, target Publisher, now time.Time, limit int) (int, error) {
+	if p, ok := target.(*RedisPublisher); ok {
+		stream := "0"
+		if p.stream {
+			stream = "1"
+		}
+		keys := []string{s.key, p.queueName}
+		return moveDue.Run(ctx, s.client, keys, now.UnixMilli(), limit, stream, p.maxLen, streamField).Int()
+	}
+
+	due, err := takeDue.Run(ctx, s.client, []string{s.key}, now.UnixMilli(), limit).StringSlice()
+	if err != nil {
+		return 0, err
+	}
+	for i, payload := range due {
+		if err := publishPayload(ctx, target, []byte(payload)); err != nil {
+			// Put the rest back for the next round
+			for _, rest := range due[i:] {
+				if err := s.add(ctx, now, []byte(rest)); err != nil {
+					log.Printf("[Retry] %s: lost a due job: %v", s.key, err)
+				}
+			}
+			return i, err
+		}
+	}
+	return len(due), nil
+}
+
+func (s *redisSchedule) size(ctx context.Context) (int64, error) {
+	return s.client.ZCard(ctx, s.key).Result()
+}
+
+// memorySchedule keeps payloads in process memory
+type memorySchedule struct {
+	mu    sync.Mutex
+	items []scheduledPayload
+}
+
+type scheduledPayload struct {
+	due     time.Time
+	payload []byte
+}
+
+func (s *memorySchedule) add(ctx context.Context, due time.Time, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = append(s.items, scheduledPayload{due: due, payload: payload})
+	return nil
+}
+
+func (s *memorySchedule) move(ctx context.Context, target Publisher, now time.Time, limit int) (int, error) {
+	s.mu.Lock()
+	var due []scheduledPayload
+	waiting := s.items[:0]
+	for _, item := range s.items {
+		if len(due) < limit && !item.due.After(now) {
+			due = append(due, item)
+		} else {
+			waiting = append(waiting, item)
+		}
+	}
+	s.items = waiting
+	s.mu.Unlock()
+
+	for i, item := range due {
+		if err := publishPayload(ctx, target, item.payload); err != nil {
+			s.mu.Lock()
+			s.items = append(s.items, due[i:]...)
+			s.mu.Unlock()
+			return i, err
+		}
+	}
+	return len(due), nil
+}
+
+func (s *memorySchedule) size(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.items)), nil
+}
+
+// publishPayload pushes a requeued envelope to target
+func publishPayload(ctx context.Context, target Publisher, payload []byte) error {
+	env, err := decodeEnvelope(payload)
+	if err != nil {
+		return err
+	}
+	return target.PublishEnvelope(ctx, &env)
+}
+
+// queueName names a publisher's queue for logs and defaults
+func queueName(p Publisher) string {
+	switch p := p.(type) {
+	case *RedisPublisher:
+		return p.queueName
+	case *MemoryQueue:
+		return p.name
+	}
+	return fmt.Sprintf("%T", p)
+}
@@ -0,0 +1,184 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/domain"
+)
+
+// testDelivery is a delivery of job id from queue on its given attempt
+func testDelivery(t *testing.T, queue, id string, attempts int) *Delivery {
+	t.Helper()
+	env := newEnvelope(context.Background(), DefaultProducer(), &domain.RawJob{ID: id, Source: "topcv"})
+	env.Attempts = attempts
+	data, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := newDelivery(queue, string(data), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestRetrySchedulesWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	r := NewRetrier(client, NewPublisher(client, "jobs:raw"), nil, RetryConfig{BaseDelay: time.Minute})
+
+	d := testDelivery(t, "jobs:raw", "1", 2)
+	before := time.Now()
+	if err := r.Retry(ctx, d, errors.New("index down")); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduled, err := client.ZRangeWithScores(ctx, "retry:jobs:raw", 0, -1).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scheduled) != 1 {
+		t.Fatalf("scheduled %d jobs, want 1", len(scheduled))
+	}
+	// The second attempt waits BaseDelay doubled, with jitter over its upper half
+	due := time.UnixMilli(int64(scheduled[0].Score))
+	if wait := due.Sub(before); wait < time.Minute-time.Second || wait > 2*time.Minute+time.Second {
+		t.Errorf("retry due in %s, want 1m-2m", wait)
+	}
+
+	env, err := decodeEnvelope([]byte(scheduled[0].Member.(string)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.ID != d.Envelope.ID || env.Attempts != 3 {
+		t.Errorf("scheduled envelope %s attempt %d, want %s attempt 3", env.ID, env.Attempts, d.Envelope.ID)
+	}
+
+	// Nothing is due yet
+	if n, err := r.Move(ctx); err != nil || n != 0 {
+		t.Errorf("move = %d, %v; want 0", n, err)
+	}
+}
+
+func TestRetryBackoffCapped(t *testing.T) {
+	r := newRetrier(NewMemoryQueue("jobs", 1, time.Second), nil, RetryConfig{BaseDelay: 30 * time.Second, MaxDelay: time.Hour})
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 15 * time.Second, 30 * time.Second},
+		{3, time.Minute, 2 * time.Minute},
+		{8, 30 * time.Minute, time.Hour}, // 30s << 7 = 64m, capped
+		{100, 30 * time.Minute, time.Hour},
+	}
+	for _, tt := range tests {
+		for range 50 {
+			if d := r.backoff(tt.attempt); d < tt.min || d > tt.max {
+				t.Errorf("backoff(%d) = %s, want %s-%s", tt.attempt, d, tt.min, tt.max)
+				break
+			}
+		}
+	}
+}
+
+func TestRetryDeadLettersAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	store := NewDeadLetterStore(client, DeadLetterConfig{})
+	r := NewRetrier(client, NewPublisher(client, "jobs:raw"), store, RetryConfig{Stage: "index", MaxAttempts: 3})
+
+	if !r.willRetry(testDelivery(t, "jobs:raw", "1", 2)) {
+		t.Error("attempt 2 of 3 is not retried")
+	}
+
+	d := testDelivery(t, "jobs:raw", "1", 3)
+	if err := r.Retry(ctx, d, errors.New("bad document")); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := r.Scheduled(ctx); n != 0 {
+		t.Errorf("scheduled = %d, want 0", n)
+	}
+
+	dl, err := store.Get(ctx, "index", "topcv:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dl == nil {
+		t.Fatal("job out of attempts was not dead-lettered")
+	}
+	if dl.Attempts != 3 || dl.Error != "bad document" || dl.Queue != "jobs:raw" || dl.Payload != d.Payload() {
+		t.Errorf("dead letter = %+v, want 3 attempts of the original payload from jobs:raw", dl)
+	}
+}
+
+func TestRetryMoveToList(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	r := NewRetrier(client, NewPublisher(client, "jobs:raw"), nil, RetryConfig{BaseDelay: 10 * time.Millisecond})
+
+	for _, id := range []string{"1", "2"} {
+		if err := r.Retry(ctx, testDelivery(t, "jobs:raw", id, 1), errors.New("timeout")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if n, err := r.Move(ctx); err != nil || n != 2 {
+		t.Fatalf("move = %d, %v; want 2", n, err)
+	}
+	if n, _ := r.Scheduled(ctx); n != 0 {
+		t.Errorf("scheduled after move = %d, want 0", n)
+	}
+
+	c := NewReliableConsumer(client, "jobs:raw", ReliableConfig{Name: "a", Timeout: time.Second})
+	deliveries, err := c.ConsumeBatch(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 2 {
+		t.Fatalf("queue holds %v, want 2 jobs", deliveryIDs(deliveries))
+	}
+	for _, d := range deliveries {
+		if d.Envelope.Attempts != 2 {
+			t.Errorf("job %s on attempt %d, want 2", d.Job.ID, d.Envelope.Attempts)
+		}
+	}
+}
+
+func TestRetryMoveToStream(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	target := NewStreamPublisher(client, "jobs:raw:group:workers", 1)
+	r := NewRetrier(client, target, nil, RetryConfig{BaseDelay: 10 * time.Millisecond})
+
+	d := testDelivery(t, "jobs:raw", "1", 1)
+	if err := r.Retry(ctx, d, errors.New("timeout")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if n, err := r.Move(ctx); err != nil || n != 1 {
+		t.Fatalf("move = %d, %v; want 1", n, err)
+	}
+
+	entries, err := client.XRange(ctx, "jobs:raw:group:workers", "-", "+").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("stream holds %d entries, want 1", len(entries))
+	}
+	payload, _ := entries[0].Values[streamField].(string)
+	env, err := decodeEnvelope([]byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.ID != d.Envelope.ID || env.Attempts != 2 {
+		t.Errorf("moved envelope %s attempt %d, want %s attempt 2", env.ID, env.Attempts, d.Envelope.ID)
+	}
+}
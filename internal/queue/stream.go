@@ -0,0 +1,373 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// streamField is the stream entry field holding the JSON payload
+const streamField = "data"
+
+// StreamConfig holds stream consumer options
+type StreamConfig struct {
+	// Group is the consumer group; every group receives every entry (default "workers")
+	Group string
+	// Name identifies this consumer inside the group (default hostname)
+	Name string
+	// Timeout is how long a read blocks waiting for entries (default 5s)
+	Timeout time.Duration
+	// VisibilityTimeout is how long an entry may stay pending before the
+	// reaper claims it for redelivery (default 5m)
+	VisibilityTimeout time.Duration
+	// ReapInterval is how often RunReaper claims stuck entries (default 1m)
+	ReapInterval time.Duration
+	// StartID is where a newly created group starts reading: "0" for the
+	// whole retained stream (default) or "$" for new entries only
+	StartID string
+}
+
+// StreamConsumer reads a Redis stream through a consumer group with
+// XREADGROUP; entries stay pending until acknowledged with XACK, and entries
+// stuck with a crashed or failing consumer are claimed back with XAUTOCLAIM
+// It also reads the group's own stream (<stream>:group:<group>), where
+// retries and replays for this group alone are published, so they do not
+// reach the other groups of the shared stream
+// It offers the same ConsumeBatch/Ack/Reap API as ReliableConsumer
+type StreamConsumer struct {
+	client *redis.Client
+	stream string
+	config StreamConfig
+
+	failures failures
+
+	mu      sync.Mutex
+	claimed []streamMessage // Reclaimed entries waiting for redelivery
+}
+
+// streamMessage is an entry with the stream it was read from
+type streamMessage struct {
+	stream string
+	redis.XMessage
+}
+
+// NewStreamConsumer creates a consumer group reader for a stream
+func NewStreamConsumer(client *redis.Client, stream string, cfg StreamConfig) *StreamConsumer {
+	if stream == "" {
+		stream = "jobs:raw"
+	}
+	if cfg.Group == "" {
+		cfg.Group = "workers"
+	}
+	if cfg.Name == "" {
+		cfg.Name, _ = os.Hostname()
+		if cfg.Name == "" {
+			cfg.Name = fmt.Sprintf("pid-%d", os.Getpid())
+		}
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.VisibilityTimeout <= 0 {
+		cfg.VisibilityTimeout = 5 * time.Minute
+	}
+	if cfg.ReapInterval <= 0 {
+		cfg.ReapInterval = time.Minute
+	}
+	if cfg.StartID == "" {
+		cfg.StartID = "0"
+	}
+	return &StreamConsumer{
+		client: client,
+		stream: stream,
+		config: cfg,
+	}
+}
+
+// WithDeadLetters dead-letters malformed entries and entries the Run handler
+// fails on as stage, instead of dropping or redelivering them
+func (c *StreamConsumer) WithDeadLetters(sink DeadLetterer, stage string) *StreamConsumer {
+	c.failures.sink, c.failures.stage = sink, stage
+	return c
+}
+
+// WithRetries schedules jobs the Run handler fails on for a delayed retry,
+// dead-lettered once out of attempts
+func (c *StreamConsumer) WithRetries(r *Retrier) *StreamConsumer {
+	c.failures.retrier = r
+	return c
+}
+
+// GroupStream is the stream only this consumer group reads: retries and
+// replays of its jobs go there instead of the shared stream
+func (c *StreamConsumer) GroupStream() string {
+	return c.stream + ":group:" + c.config.Group
+}
+
+// GroupPublisher publishes to the group's own stream
+func (c *StreamConsumer) GroupPublisher() *RedisPublisher {
+	return NewStreamPublisher(c.client, c.GroupStream(), 0)
+}
+
+// EnsureGroup creates the streams and consumer group when missing
+func (c *StreamConsumer) EnsureGroup(ctx context.Context) error {
+	for _, stream := range c.streams() {
+		// The group stream only holds entries this group has not read yet
+		start := "0"
+		if stream == c.stream {
+			start = c.config.StartID
+		}
+		err := c.client.XGroupCreateMkStream(ctx, stream, c.config.Group, start).Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return fmt.Errorf("create group %s on %s: %w", c.config.Group, stream, err)
+		}
+	}
+	return nil
+}
+
+// ConsumeBatch returns up to maxBatch entries, reclaimed ones first, then new
+// entries read with XREADGROUP from both streams (blocking up to Timeout for
+// the first)
+// Malformed entries are dead-lettered, acknowledged and skipped
+func (c *StreamConsumer) ConsumeBatch(ctx context.Context, maxBatch int) ([]*Delivery, error) {
+	messages := c.takeClaimed(maxBatch)
+	if len(messages) == 0 {
+		var err error
+		messages, err = c.read(ctx, maxBatch)
+		if err != nil {
+			return nil, err
+		}
+		// COUNT applies per stream; the rest is already pending here
+		if len(messages) > maxBatch {
+			c.addClaimed(messages[maxBatch:])
+			messages = messages[:maxBatch]
+		}
+	}
+
+	deliveries := make([]*Delivery, 0, len(messages))
+	var malformed []*Delivery
+	for _, msg := range messages {
+		payload, _ := msg.Values[streamField].(string)
+		d, err := newDelivery(msg.stream, payload, msg.ID)
+		d.requeue = c.GroupStream()
+		if err != nil {
+			log.Printf("[Queue] %s: dropping malformed entry %s: %v", msg.stream, msg.ID, err)
+			c.failures.send(ctx, d, err)
+			malformed = append(malformed, d)
+			continue
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := c.Ack(ctx, malformed...); err != nil {
+		log.Printf("[Queue] %s: %v", c.stream, err)
+	}
+	return deliveries, nil
+}
+
+// Ack acknowledges handled entries for this consumer group
+// Entries stay in the shared stream for other groups until MAXLEN trims
+// them; entries of the group stream are deleted
+func (c *StreamConsumer) Ack(ctx context.Context, deliveries ...*Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	ids := make(map[string][]string)
+	for _, d := range deliveries {
+		ids[d.queue] = append(ids[d.queue], d.id)
+	}
+	pipe := c.client.TxPipeline()
+	for stream, streamIDs := range ids {
+		pipe.XAck(ctx, stream, c.config.Group, streamIDs...)
+		if stream == c.GroupStream() {
+			pipe.XDel(ctx, stream, streamIDs...)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("xack: %w", err)
+	}
+	return nil
+}
+
+// Run starts a continuous consumer loop; entries are acknowledged once
+// handler succeeds, failed ones are dead-lettered, or redelivered after
+// VisibilityTimeout without a dead-letter store
+func (c *StreamConsumer) Run(ctx context.Context, handler Handler) error {
+	return run(ctx, c, c.stream, c.failures, handler)
+}
+
+// Recover queues the entries this consumer name still has pending (left by
+// a previous process) for redelivery; call it on startup, before consuming
+func (c *StreamConsumer) Recover(ctx context.Context) (int, error) {
+	total := 0
+	for _, stream := range c.streams() {
+		start := "0"
+		for {
+			streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    c.config.Group,
+				Consumer: c.config.Name,
+				Streams:  []string{stream, start},
+				Count:    100,
+				Block:    -1,
+			}).Result()
+			if err != nil && err != redis.Nil {
+				return total, fmt.Errorf("read pending %s: %w", stream, err)
+			}
+			if len(streams) == 0 || len(streams[0].Messages) == 0 {
+				break
+			}
+			messages := streams[0].Messages
+			total += c.addClaimed(tagged(stream, messages))
+			start = messages[len(messages)-1].ID
+		}
+	}
+	return total, nil
+}
+
+// Reap claims entries pending longer than VisibilityTimeout in the group
+// (from any consumer) with XAUTOCLAIM and queues them for redelivery here;
+// entries already waiting here are not queued twice
+func (c *StreamConsumer) Reap(ctx context.Context) (int, error) {
+	total := 0
+	for _, stream := range c.streams() {
+		start := "0-0"
+		for {
+			messages, next, err := c.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+				Stream:   stream,
+				Group:    c.config.Group,
+				Consumer: c.config.Name,
+				MinIdle:  c.config.VisibilityTimeout,
+				Start:    start,
+				Count:    100,
+			}).Result()
+			if err != nil {
+				return total, fmt.Errorf("xautoclaim %s: %w", stream, err)
+			}
+			total += c.addClaimed(tagged(stream, messages))
+			if next == "0-0" || next == "" {
+				break
+			}
+			start = next
+		}
+	}
+	return total, nil
+}
+
+// RunReaper calls Reap every ReapInterval until ctx is cancelled
+func (c *StreamConsumer) RunReaper(ctx context.Context) {
+	ticker := time.NewTicker(c.config.ReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := c.Reap(ctx)
+		if err != nil {
+			log.Printf("[Queue] %s: reap error: %v", c.stream, err)
+		}
+		if n > 0 {
+			log.Printf("[Queue] %s: claimed %d stale pending entries for group %s", c.stream, n, c.config.Group)
+		}
+	}
+}
+
+// Pending returns the number of entries delivered to the group but not yet acknowledged
+func (c *StreamConsumer) Pending(ctx context.Context) (int64, error) {
+	var total int64
+	for _, stream := range c.streams() {
+		pending, err := c.client.XPending(ctx, stream, c.config.Group).Result()
+		if err != nil {
+			return 0, fmt.Errorf("xpending %s: %w", stream, err)
+		}
+		total += pending.Count
+	}
+	return total, nil
+}
+
+// streams are the shared stream and the group's own one
+func (c *StreamConsumer) streams() []string {
+	return []string{c.stream, c.GroupStream()}
+}
+
+// read fetches new entries for the group from both streams, creating the
+// group on first use
+func (c *StreamConsumer) read(ctx context.Context, count int) ([]streamMessage, error) {
+	args := &redis.XReadGroupArgs{
+		Group:    c.config.Group,
+		Consumer: c.config.Name,
+		Streams:  append(c.streams(), ">", ">"),
+		Count:    int64(count),
+		Block:    c.config.Timeout,
+	}
+	streams, err := c.client.XReadGroup(ctx, args).Result()
+	if err != nil && strings.HasPrefix(err.Error(), "NOGROUP") {
+		if err := c.EnsureGroup(ctx); err != nil {
+			return nil, err
+		}
+		streams, err = c.client.XReadGroup(ctx, args).Result()
+	}
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil // Timeout, no entries
+		}
+		return nil, fmt.Errorf("xreadgroup: %w", err)
+	}
+	var messages []streamMessage
+	for _, stream := range streams {
+		messages = append(messages, tagged(stream.Stream, stream.Messages)...)
+	}
+	return messages, nil
+}
+
+func tagged(stream string, messages []redis.XMessage) []streamMessage {
+	result := make([]streamMessage, len(messages))
+	for i, msg := range messages {
+		result[i] = streamMessage{stream: stream, XMessage: msg}
+	}
+	return result
+}
+
+// addClaimed queues entries for redelivery, skipping those already queued
+// (a Reap claims them again while they wait), and returns how many it queued
+func (c *StreamConsumer) addClaimed(messages []streamMessage) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queued := make(map[[2]string]bool, len(c.claimed))
+	for _, msg := range c.claimed {
+		queued[[2]string{msg.stream, msg.ID}] = true
+	}
+	added := 0
+	for _, msg := range messages {
+		key := [2]string{msg.stream, msg.ID}
+		if queued[key] {
+			continue
+		}
+		queued[key] = true
+		c.claimed = append(c.claimed, msg)
+		added++
+	}
+	return added
+}
+
+func (c *StreamConsumer) takeClaimed(n int) []streamMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n = min(n, len(c.claimed))
+	if n <= 0 {
+		return nil
+	}
+	messages := c.claimed[:n:n]
+	c.claimed = c.claimed[n:]
+	return messages
+}
@@ -0,0 +1,207 @@
+package queue
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/project-tktt/go-crawler/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// publishStream publishes jobs with the given IDs to a stream
+func publishStream(t *testing.T, client *redis.Client, stream string, ids ...string) {
+	t.Helper()
+	pub := NewStreamPublisher(client, stream, 0)
+	for _, id := range ids {
+		if err := pub.Publish(context.Background(), &domain.RawJob{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func sortedIDs(deliveries []*Delivery) []string {
+	ids := deliveryIDs(deliveries)
+	sort.Strings(ids)
+	return ids
+}
+
+func TestStreamEnsureGroup(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	publishStream(t, client, "jobs:raw", "old")
+
+	c := NewStreamConsumer(client, "jobs:raw", StreamConfig{Group: "indexer", Name: "a", StartID: "$", Timeout: 50 * time.Millisecond})
+	for range 2 { // Creating an existing group is not an error
+		if err := c.EnsureGroup(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, stream := range []string{"jobs:raw", "jobs:raw:group:indexer"} {
+		groups, err := client.XInfoGroups(ctx, stream).Result()
+		if err != nil {
+			t.Fatalf("groups of %s: %v", stream, err)
+		}
+		if len(groups) != 1 || groups[0].Name != "indexer" {
+			t.Errorf("groups of %s = %+v, want indexer", stream, groups)
+		}
+	}
+
+	// StartID "$" skips what the shared stream held before the group existed
+	publishStream(t, client, "jobs:raw", "new")
+	deliveries, err := c.ConsumeBatch(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := deliveryIDs(deliveries); len(ids) != 1 || ids[0] != "new" {
+		t.Errorf("consumed %v, want [new]", ids)
+	}
+}
+
+func TestStreamCreatesGroupOnRead(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	publishStream(t, client, "jobs:raw", "1")
+
+	c := NewStreamConsumer(client, "jobs:raw", StreamConfig{Name: "a", Timeout: 50 * time.Millisecond})
+	deliveries, err := c.ConsumeBatch(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := deliveryIDs(deliveries); len(ids) != 1 || ids[0] != "1" {
+		t.Errorf("consumed %v, want [1]", ids)
+	}
+}
+
+func TestStreamAck(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+
+	c := NewStreamConsumer(client, "jobs:raw", StreamConfig{Name: "a", Timeout: 50 * time.Millisecond})
+	if err := c.EnsureGroup(ctx); err != nil {
+		t.Fatal(err)
+	}
+	publishStream(t, client, "jobs:raw", "shared")
+	publishStream(t, client, c.GroupStream(), "retry")
+
+	deliveries, err := c.ConsumeBatch(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := sortedIDs(deliveries); len(ids) != 2 || ids[0] != "retry" || ids[1] != "shared" {
+		t.Fatalf("consumed %v, want [retry shared]", ids)
+	}
+	for _, d := range deliveries {
+		if d.requeue != c.GroupStream() {
+			t.Errorf("requeue of %s = %q, want the group stream", d.Job.ID, d.requeue)
+		}
+	}
+	if n, _ := c.Pending(ctx); n != 2 {
+		t.Errorf("pending before ack = %d, want 2", n)
+	}
+
+	if err := c.Ack(ctx, deliveries...); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := c.Pending(ctx); n != 0 {
+		t.Errorf("pending after ack = %d, want 0", n)
+	}
+	// Other groups still read the shared stream; the group stream is this group's alone
+	if n, _ := client.XLen(ctx, "jobs:raw").Result(); n != 1 {
+		t.Errorf("shared stream length = %d, want 1 (kept)", n)
+	}
+	if n, _ := client.XLen(ctx, c.GroupStream()).Result(); n != 0 {
+		t.Errorf("group stream length = %d, want 0 (deleted)", n)
+	}
+}
+
+func TestStreamReadOverMaxBatch(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+
+	c := NewStreamConsumer(client, "jobs:raw", StreamConfig{Name: "a", Timeout: 50 * time.Millisecond})
+	if err := c.EnsureGroup(ctx); err != nil {
+		t.Fatal(err)
+	}
+	publishStream(t, client, "jobs:raw", "1", "2", "3")
+	publishStream(t, client, c.GroupStream(), "4", "5")
+
+	// COUNT applies per stream, so one read returns 4 entries for a batch of 2
+	first, err := c.ConsumeBatch(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 {
+		t.Fatalf("first batch = %v, want 2 jobs", deliveryIDs(first))
+	}
+	if len(c.claimed) != 2 {
+		t.Fatalf("claimed = %d entries, want the 2 extra ones", len(c.claimed))
+	}
+
+	// The extras are delivered next, before anything new is read
+	second, err := c.ConsumeBatch(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	third, err := c.ConsumeBatch(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := sortedIDs(append(append(first, second...), third...))
+	if len(got) != 5 || got[0] != "1" || got[4] != "5" {
+		t.Errorf("consumed %v, want [1 2 3 4 5] once each", got)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] == got[i-1] {
+			t.Errorf("job %s delivered twice", got[i])
+		}
+	}
+}
+
+func TestStreamReapSkipsClaimed(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+
+	c := NewStreamConsumer(client, "jobs:raw", StreamConfig{Name: "a", Timeout: 50 * time.Millisecond, VisibilityTimeout: 50 * time.Millisecond})
+	if err := c.EnsureGroup(ctx); err != nil {
+		t.Fatal(err)
+	}
+	publishStream(t, client, "jobs:raw", "1")
+	publishStream(t, client, c.GroupStream(), "2")
+
+	// Job 1 is in flight, job 2 waits in the claimed buffer; both are pending
+	deliveries, err := c.ConsumeBatch(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || len(c.claimed) != 1 {
+		t.Fatalf("consumed %v with %d claimed, want 1 and 1", deliveryIDs(deliveries), len(c.claimed))
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	n, err := c.Reap(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("reaped %d entries, want 1 (the stale in-flight one)", n)
+	}
+	if len(c.claimed) != 2 {
+		t.Errorf("claimed = %d entries, want 2 without duplicates", len(c.claimed))
+	}
+
+	// A second reap finds only entries already waiting here
+	time.Sleep(100 * time.Millisecond)
+	if n, err := c.Reap(ctx); err != nil || n != 0 {
+		t.Errorf("second reap = %d, %v; want 0", n, err)
+	}
+	redelivered, err := c.ConsumeBatch(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := sortedIDs(redelivered); len(ids) != 2 || ids[0] != "1" || ids[1] != "2" {
+		t.Errorf("redelivered %v, want [1 2]", ids)
+	}
+}